@@ -51,3 +51,13 @@ func BenchmarkArena(b *testing.B) {
 		arena.Release()
 	}
 }
+
+func BenchmarkArenaZero(b *testing.B) {
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		arena := NewArena(Global(), 1<<16)
+		arena.Get(1<<16, true)
+		arena.Release()
+	}
+}
@@ -0,0 +1,95 @@
+package fpack
+
+import "fmt"
+
+// BatchError is returned if the encoding of a message in a batch failed.
+type BatchError struct {
+	Index int
+	Err   error
+}
+
+// Error implements the error interface.
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("message %d: %s", e.Index, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+// EncodeMany will encode multiple messages using the provided encoding
+// functions into a single buffer. The functions are run once to assess the
+// length of all messages and once to encode them back to back. The returned
+// slices share the buffer that is owned by the returned Ref. Any error returned
+// by a callback is returned immediately as a BatchError.
+func EncodeMany(pool *Pool, fns []func(enc *Encoder) error) ([][]byte, Ref, error) {
+	// borrow
+	enc := encoderPool.Get().(*Encoder)
+
+	// recycle
+	defer func() {
+		enc.Reset(nil)
+		encoderPool.Put(enc)
+	}()
+
+	// count
+	var total int
+	lengths := make([]int, len(fns))
+	cmis := make([]int, len(fns))
+	beis := make([]int, len(fns))
+	for i, fn := range fns {
+		// reset encoder but keep cache of previous messages
+		cmp, ber := enc.cmp, enc.ber
+		enc.Reset(nil)
+		enc.cmp, enc.ber = cmp, ber
+		cmis[i] = len(enc.cmp)
+		beis[i] = len(enc.ber)
+
+		// count message
+		err := fn(enc)
+		if err == nil {
+			err = enc.Error()
+		}
+		if err != nil {
+			return nil, Ref{}, &BatchError{Index: i, Err: err}
+		}
+		lengths[i] = enc.Length()
+		total += lengths[i]
+	}
+
+	// get buffer
+	var buf []byte
+	var ref Ref
+	if pool != nil {
+		buf, ref = pool.Borrow(total, false)
+	} else {
+		buf = make([]byte, total)
+	}
+
+	// encode
+	var off int
+	msgs := make([][]byte, len(fns))
+	for i, fn := range fns {
+		// reset encoder and seek cache
+		msg := buf[off : off+lengths[i] : off+lengths[i]]
+		enc.Reset(msg)
+		enc.cmi = cmis[i]
+		enc.bei = beis[i]
+
+		// encode message
+		err := fn(enc)
+		if err == nil {
+			err = enc.Error()
+		}
+		if err != nil {
+			ref.Release()
+			return nil, Ref{}, &BatchError{Index: i, Err: err}
+		}
+
+		msgs[i] = msg
+		off += lengths[i]
+	}
+
+	return msgs, ref, nil
+}
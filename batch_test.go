@@ -0,0 +1,97 @@
+package fpack
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeMany(t *testing.T) {
+	withAndWithoutPool(func(pool *Pool) {
+		msgs, ref, err := EncodeMany(pool, []func(enc *Encoder) error{
+			func(enc *Encoder) error {
+				enc.Uint8(1)
+				return nil
+			},
+			func(enc *Encoder) error {
+				enc.String("foo")
+				return nil
+			},
+			func(enc *Encoder) error {
+				return nil
+			},
+			func(enc *Encoder) error {
+				encodeDummy(enc)
+				return nil
+			},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, [][]byte{{1}, []byte("foo"), {}, dummy}, msgs)
+		ref.Release()
+
+		msgs, ref, err = EncodeMany(pool, []func(enc *Encoder) error{
+			func(enc *Encoder) error {
+				enc.Uint8(1)
+				return nil
+			},
+			func(enc *Encoder) error {
+				return io.EOF
+			},
+		})
+		assert.Equal(t, &BatchError{Index: 1, Err: io.EOF}, err)
+		assert.True(t, errors.Is(err, io.EOF))
+		assert.Equal(t, "message 1: EOF", err.Error())
+		assert.Nil(t, msgs)
+		assert.Zero(t, ref)
+
+		msgs, ref, err = EncodeMany(pool, []func(enc *Encoder) error{
+			func(enc *Encoder) error {
+				enc.Uint(1, 3)
+				return nil
+			},
+		})
+		assert.Equal(t, &BatchError{Index: 0, Err: ErrInvalidSize}, err)
+		assert.Nil(t, msgs)
+		assert.Zero(t, ref)
+	})
+}
+
+func TestEncodeManyCalls(t *testing.T) {
+	var berCalls, cmpCalls int
+	var nest func(enc *Encoder, depth int)
+	nest = func(enc *Encoder, depth int) {
+		berCalls++
+		enc.Uint8(uint8(depth))
+		if depth > 0 {
+			enc.BERBlock(func(enc *Encoder) {
+				nest(enc, depth-1)
+			})
+		}
+		enc.Bytes(bytes.Repeat([]byte{'x'}, 100))
+	}
+
+	fn := func(enc *Encoder) error {
+		nest(enc, 10)
+		enc.CompressedBlock(flateCompressor{}, func(enc *Encoder) {
+			cmpCalls++
+			enc.String("foo")
+		})
+		return nil
+	}
+
+	buf, _, err := Encode(nil, fn)
+	assert.NoError(t, err)
+	assert.Equal(t, 22, berCalls)
+	assert.Equal(t, 2, cmpCalls)
+
+	berCalls = 0
+	cmpCalls = 0
+	msgs, _, err := EncodeMany(nil, []func(enc *Encoder) error{fn, fn, fn})
+	assert.NoError(t, err)
+	assert.Equal(t, [][]byte{buf, buf, buf}, msgs)
+	assert.Equal(t, 66, berCalls)
+	assert.Equal(t, 6, cmpCalls)
+}
@@ -0,0 +1,216 @@
+package fpack
+
+import (
+	"errors"
+	"math"
+	"math/bits"
+)
+
+// ErrIndefiniteLength is returned if a decoded BER length uses the indefinite
+// form.
+var ErrIndefiniteLength = errors.New("indefinite length")
+
+func berSize(length int) int {
+	if length < 0x80 {
+		return 1
+	}
+	return 1 + (bits.Len64(uint64(length))+7)/8
+}
+
+func putBERLength(buf []byte, length, size int) {
+	// write short form
+	if size == 1 {
+		buf[0] = uint8(length)
+		return
+	}
+
+	// write long form
+	buf[0] = 0x80 | uint8(size-1)
+	for i := 0; i < size-1; i++ {
+		buf[size-1-i] = uint8(length >> (i * 8))
+	}
+}
+
+// BERLength writes an ASN.1 BER definite length. Lengths below 128 use the
+// short form, other lengths use the long form.
+func (e *Encoder) BERLength(length int) {
+	// skip if errored
+	if e.err != nil {
+		return
+	}
+
+	// check length
+	if length < 0 {
+		e.err = ErrInvalidSize
+		return
+	}
+
+	// handle short form
+	if length < 0x80 {
+		e.Uint8(uint8(length))
+		return
+	}
+
+	// determine size
+	size := berSize(length)
+
+	// handle length
+	if e.buf == nil {
+		if e.rcd {
+			e.render(size, func(enc *Encoder) {
+				enc.BERLength(length)
+			})
+		}
+		e.len += size
+		return
+	}
+
+	// grow buffer
+	if !e.grow("berlength", size) {
+		return
+	}
+
+	// write long form
+	putBERLength(e.buf, length, size)
+
+	// trace
+	if e.trc != nil {
+		e.trc("berlength", e.Offset(), size)
+	}
+
+	// slice
+	e.buf = e.buf[size:]
+}
+
+// BERBlock writes an ASN.1 BER definite length followed by the data written by
+// the provided function. The function is called once per pass. The lengths
+// measured while counting are cached for the following write pass. Without a
+// counting pass, the length is patched in after the data has been written.
+func (e *Encoder) BERBlock(fn func(enc *Encoder)) {
+	// skip if errored
+	if e.err != nil {
+		return
+	}
+
+	// handle length
+	if e.buf == nil && e.rcd {
+		i := len(e.rec)
+		e.record(op{code: opBER})
+		start := e.len
+		fn(e)
+		e.rec[i].size = e.len - start
+		e.len += berSize(e.len - start)
+		return
+	} else if e.buf == nil {
+		i := len(e.ber)
+		e.ber = append(e.ber, 0)
+		start := e.len
+		fn(e)
+		e.ber[i] = e.len - start
+		e.len += berSize(e.len - start)
+		return
+	}
+
+	// write cached length and data
+	if e.bei < len(e.ber) {
+		length := e.ber[e.bei]
+		e.bei++
+		e.BERLength(length)
+		start := e.Offset()
+		fn(e)
+		if e.err == nil && e.Offset()-start != length {
+			e.err = ErrSizeMismatch
+		}
+		return
+	}
+
+	// reserve short form length
+	if !e.grow("berblock", 1) {
+		return
+	}
+	hdr := len(e.org) - len(e.buf)
+	e.buf = e.buf[1:]
+
+	// write data
+	start := e.Offset()
+	fn(e)
+	if e.err != nil {
+		return
+	}
+	length := e.Offset() - start
+
+	// move data if the long form is needed
+	size := berSize(length)
+	if size > 1 {
+		if !e.grow("berblock", size-1) {
+			return
+		}
+		copy(e.org[hdr+size:], e.org[hdr+1:hdr+1+length])
+		e.buf = e.buf[size-1:]
+	}
+
+	// patch length
+	putBERLength(e.org[hdr:], length, size)
+
+	// trace
+	if e.trc != nil {
+		e.trc("berlength", e.fls+hdr, size)
+	}
+}
+
+// BERLength reads an ASN.1 BER definite length. The indefinite form is rejected
+// with ErrIndefiniteLength and lengths that overflow an int with
+// ErrNumberOverflow.
+func (d *Decoder) BERLength() int {
+	// read first byte
+	first := d.uint("berlength", 1)
+	if d.err != nil {
+		return 0
+	}
+
+	// handle short form
+	if first < 0x80 {
+		return int(first)
+	}
+
+	// check indefinite form
+	if first == 0x80 {
+		d.failAt(ErrIndefiniteLength, "berlength", d.Offset()-1, 1)
+		return 0
+	}
+
+	// check size
+	size := int(first & 0x7F)
+	if size > 8 {
+		d.failAt(ErrNumberOverflow, "berlength", d.Offset()-1, size)
+		return 0
+	}
+
+	// check buffer
+	if !d.need(size) {
+		d.fail(ErrBufferTooShort, "berlength", size)
+		return 0
+	}
+
+	// read long form
+	var length uint64
+	for _, b := range d.buf[:size] {
+		length = length<<8 | uint64(b)
+	}
+
+	// check overflow
+	if length > math.MaxInt {
+		d.failAt(ErrNumberOverflow, "berlength", d.Offset()-1, size)
+		return 0
+	}
+
+	// trace
+	if d.trc != nil {
+		d.trc("berlength", d.Offset(), size, length)
+	}
+
+	// slice
+	d.buf = d.buf[size:]
+
+	return int(length)
+}
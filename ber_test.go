@@ -0,0 +1,148 @@
+package fpack
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBERLength(t *testing.T) {
+	table := []struct {
+		len int
+		enc []byte
+	}{
+		{len: 0, enc: []byte{0x00}},
+		{len: 127, enc: []byte{0x7F}},
+		{len: 128, enc: []byte{0x81, 0x80}},
+		{len: 255, enc: []byte{0x81, 0xFF}},
+		{len: 256, enc: []byte{0x82, 0x01, 0x00}},
+		{len: 0x123456, enc: []byte{0x83, 0x12, 0x34, 0x56}},
+		{len: math.MaxInt64, enc: []byte{0x88, 0x7F, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}},
+	}
+
+	for _, item := range table {
+		buf, _, err := Encode(nil, func(enc *Encoder) error {
+			enc.BERLength(item.len)
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, item.enc, buf)
+
+		var length int
+		err = Decode(buf, func(dec *Decoder) error {
+			length = dec.BERLength()
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, item.len, length)
+	}
+}
+
+func TestBERLengthErrors(t *testing.T) {
+	_, _, err := Encode(nil, func(enc *Encoder) error {
+		enc.BERLength(-1)
+		return nil
+	})
+	assert.Equal(t, ErrInvalidSize, err)
+
+	table := []struct {
+		enc []byte
+		err error
+	}{
+		{enc: []byte{}, err: ErrBufferTooShort},
+		{enc: []byte{0x80}, err: ErrIndefiniteLength},
+		{enc: []byte{0x82, 0x01}, err: ErrBufferTooShort},
+		{enc: []byte{0x89, 1, 2, 3, 4, 5, 6, 7, 8, 9}, err: ErrNumberOverflow},
+		{enc: []byte{0x88, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}, err: ErrNumberOverflow},
+	}
+
+	for i, item := range table {
+		err := Decode(item.enc, func(dec *Decoder) error {
+			dec.BERLength()
+			return nil
+		})
+		assert.ErrorIs(t, err, item.err, i)
+	}
+}
+
+func TestBERBlock(t *testing.T) {
+	long := bytes.Repeat([]byte{'x'}, 200)
+
+	buf, _, err := Encode(nil, func(enc *Encoder) error {
+		enc.Uint8(0x30)
+		enc.BERBlock(func(enc *Encoder) {
+			enc.Uint8(0x04)
+			enc.BERBlock(func(enc *Encoder) {
+				enc.Bytes(long)
+			})
+		})
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, append([]byte{0x30, 0x81, 0xCB, 0x04, 0x81, 0xC8}, long...), buf)
+
+	var res []byte
+	err = Decode(buf, func(dec *Decoder) error {
+		dec.Uint8()
+		dec.BERLength()
+		dec.Uint8()
+		res = dec.Bytes(dec.BERLength(), false)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, long, res)
+}
+
+func TestBERBlockCalls(t *testing.T) {
+	var calls int
+	var nest func(enc *Encoder, depth int)
+	nest = func(enc *Encoder, depth int) {
+		calls++
+		enc.Uint8(uint8(depth))
+		if depth > 0 {
+			enc.BERBlock(func(enc *Encoder) {
+				nest(enc, depth-1)
+			})
+		}
+		enc.Bytes(bytes.Repeat([]byte{'x'}, 100))
+	}
+
+	buf1, _, err := Encode(nil, func(enc *Encoder) error {
+		nest(enc, 10)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 22, calls)
+
+	calls = 0
+	buf2, ref, err := EncodeDynamic(nil, func(enc *Encoder) error {
+		nest(enc, 10)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 11, calls)
+	assert.Equal(t, buf1, buf2)
+	ref.Release()
+
+	buf3, ref, err := EncodeDynamic(nil, func(enc *Encoder) error {
+		enc.BERBlock(func(enc *Encoder) {
+			enc.Uint8(1)
+		})
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x01, 0x01}, buf3)
+	ref.Release()
+
+	n := 3
+	_, _, err = Encode(nil, func(enc *Encoder) error {
+		enc.BERBlock(func(enc *Encoder) {
+			n--
+			enc.Skip(n)
+		})
+		return nil
+	})
+	assert.Equal(t, ErrSizeMismatch, err)
+}
@@ -181,6 +181,18 @@ func (b *Buffer) Release() {
 	bufferPool.Put(b)
 }
 
+func (b *Buffer) prepare(length int) int {
+	// acquire mutex
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	// zero gap and grow buffer
+	_ = b.write(b.offset, nil)
+	b.grow(b.offset + length)
+
+	return b.offset
+}
+
 func (b *Buffer) write(off int, buf []byte) error {
 	// check offset
 	if off < 0 {
@@ -98,3 +98,17 @@ func BenchmarkBuffer(b *testing.B) {
 		b.Release()
 	}
 }
+
+func BenchmarkBufferGap(b *testing.B) {
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		b := NewBuffer(Global(), 1<<16) // 64 KiB
+		_, err := b.WriteAt(hello, 1<<16)
+		if err != nil {
+			panic(err)
+		}
+		b.Release()
+	}
+}
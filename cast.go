@@ -0,0 +1,22 @@
+//go:build !fpack_safe
+
+package fpack
+
+import "unsafe"
+
+// safeMode is enabled using the "fpack_safe" build tag.
+const safeMode = false
+
+// toString returns a string that shares the memory of the provided byte slice.
+func toString(b []byte) string {
+	return *(*string)(unsafe.Pointer(&b))
+}
+
+// toBytes returns a byte slice that shares the memory of the provided string.
+// The byte slice must not be mutated.
+func toBytes(s string) []byte {
+	return *(*[]byte)(unsafe.Pointer(&struct {
+		string
+		int
+	}{s, len(s)}))
+}
@@ -0,0 +1,17 @@
+//go:build fpack_safe
+
+package fpack
+
+// safeMode is enabled using the "fpack_safe" build tag. In safe mode, strings
+// and byte slices are always copied and decoded values are always cloned.
+const safeMode = true
+
+// toString returns a copy of the provided byte slice as a string.
+func toString(b []byte) string {
+	return string(b)
+}
+
+// toBytes returns a copy of the provided string as a byte slice.
+func toBytes(s string) []byte {
+	return []byte(s)
+}
@@ -0,0 +1,447 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const annotation = "//fpack:generate"
+
+type field struct {
+	name  string
+	typ   ast.Expr
+	rep   string
+	order int
+}
+
+type generator struct {
+	buf  bytes.Buffer
+	vars int
+}
+
+// Generate will generate the methods for the specified or annotated struct
+// types in the provided source file.
+func Generate(file string, src []byte, names []string) ([]byte, error) {
+	// parse file
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, file, src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	// collect types
+	wanted := map[string]bool{}
+	for _, name := range names {
+		wanted[name] = true
+	}
+	var specs []*ast.TypeSpec
+	for _, decl := range f.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			ts := spec.(*ast.TypeSpec)
+			if _, ok := ts.Type.(*ast.StructType); !ok {
+				continue
+			}
+			if wanted[ts.Name.Name] || annotated(gen.Doc) || annotated(ts.Doc) {
+				specs = append(specs, ts)
+				delete(wanted, ts.Name.Name)
+			}
+		}
+	}
+
+	// check missing types
+	for name := range wanted {
+		return nil, fmt.Errorf("struct type %s not found", name)
+	}
+
+	// write header
+	g := &generator{}
+	g.printf("// Code generated by fpackgen. DO NOT EDIT.\n\n")
+	g.printf("package %s\n\n", f.Name.Name)
+	g.printf("import \"github.com/256dpi/fpack\"\n")
+
+	// generate methods
+	for _, spec := range specs {
+		err = g.generate(spec)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", spec.Name.Name, err)
+		}
+	}
+
+	// format source
+	out, err := format.Source(g.buf.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func annotated(doc *ast.CommentGroup) bool {
+	if doc == nil {
+		return false
+	}
+	for _, c := range doc.List {
+		if strings.TrimSpace(c.Text) == annotation {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *generator) printf(format string, args ...any) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+func (g *generator) variable() string {
+	g.vars++
+	return "v" + strconv.Itoa(g.vars)
+}
+
+func (g *generator) generate(spec *ast.TypeSpec) error {
+	// collect fields
+	var fields []field
+	for _, f := range spec.Type.(*ast.StructType).Fields.List {
+		// get tag
+		var tag string
+		if f.Tag != nil {
+			value, err := strconv.Unquote(f.Tag.Value)
+			if err != nil {
+				return err
+			}
+			tag = reflect.StructTag(value).Get("fpack")
+		}
+		if tag == "-" {
+			continue
+		}
+
+		// parse tag
+		rep, order, err := parseTag(tag)
+		if err != nil {
+			return err
+		}
+
+		// check embedded fields
+		if len(f.Names) == 0 {
+			return fmt.Errorf("embedded field %s not supported", types.ExprString(f.Type))
+		}
+
+		// add fields
+		for _, name := range f.Names {
+			if !name.IsExported() {
+				continue
+			}
+			fields = append(fields, field{
+				name:  name.Name,
+				typ:   f.Type,
+				rep:   rep,
+				order: order,
+			})
+		}
+	}
+
+	// order fields
+	sort.SliceStable(fields, func(i, j int) bool {
+		oi, oj := fields[i].order, fields[j].order
+		if oi < 0 || oj < 0 {
+			return oi >= 0 && oj < 0
+		}
+		return oi < oj
+	})
+	for i := 1; i < len(fields); i++ {
+		if fields[i].order >= 0 && fields[i].order == fields[i-1].order {
+			return fmt.Errorf("duplicate order %d", fields[i].order)
+		}
+	}
+
+	// generate encode method
+	g.vars = 0
+	g.printf("\n// EncodeFpack will encode the value.\n")
+	g.printf("func (v *%s) EncodeFpack(enc *fpack.Encoder) {\n", spec.Name.Name)
+	for _, f := range fields {
+		err := g.encode(f.typ, "v."+f.name, f.rep)
+		if err != nil {
+			return fmt.Errorf("%s: %w", f.name, err)
+		}
+	}
+	g.printf("}\n")
+
+	// generate decode method
+	g.vars = 0
+	g.printf("\n// DecodeFpack will decode the value.\n")
+	g.printf("func (v *%s) DecodeFpack(dec *fpack.Decoder) {\n", spec.Name.Name)
+	for _, f := range fields {
+		err := g.decode(f.typ, "v."+f.name, f.rep)
+		if err != nil {
+			return fmt.Errorf("%s: %w", f.name, err)
+		}
+	}
+	g.printf("}\n")
+
+	return nil
+}
+
+func (g *generator) encode(typ ast.Expr, val, rep string) error {
+	// get representation
+	lenSize, err := checkRep(typ, rep)
+	if err != nil {
+		return err
+	}
+
+	switch typ := typ.(type) {
+	case *ast.Ident:
+		switch typ.Name {
+		case "bool":
+			g.printf("enc.Bool(%s)\n", val)
+		case "int", "int8", "int16", "int32", "int64":
+			if rep == "varint" {
+				g.printf("enc.VarInt(int64(%s))\n", val)
+			} else {
+				g.printf("enc.Int(int64(%s), %d)\n", val, intSize(typ.Name))
+			}
+		case "uint", "uint8", "byte", "uint16", "uint32", "uint64":
+			if rep == "varint" {
+				g.printf("enc.VarUint(uint64(%s))\n", val)
+			} else {
+				g.printf("enc.Uint(uint64(%s), %d)\n", val, intSize(typ.Name))
+			}
+		case "float32":
+			g.printf("enc.Float32(%s)\n", val)
+		case "float64":
+			g.printf("enc.Float64(%s)\n", val)
+		case "string":
+			if lenSize > 0 {
+				g.printf("enc.FixString(%s, %d)\n", val, lenSize)
+			} else {
+				g.printf("enc.VarString(%s)\n", val)
+			}
+		default:
+			if basic(typ.Name) {
+				return fmt.Errorf("unsupported type %s", typ.Name)
+			}
+			g.printf("%s.EncodeFpack(enc)\n", val)
+		}
+	case *ast.SelectorExpr:
+		g.printf("%s.EncodeFpack(enc)\n", val)
+	case *ast.StarExpr:
+		g.printf("enc.Option(%s != nil, func(enc *fpack.Encoder) {\n", val)
+		err = g.encode(typ.X, "(*"+val+")", rep)
+		if err != nil {
+			return err
+		}
+		g.printf("})\n")
+	case *ast.ArrayType:
+		// handle byte slices
+		if typ.Len == nil && isByte(typ.Elt) {
+			if lenSize > 0 {
+				g.printf("enc.FixBytes(%s, %d)\n", val, lenSize)
+			} else {
+				g.printf("enc.VarBytes(%s)\n", val)
+			}
+			return nil
+		}
+
+		// handle arrays and slices
+		i := g.variable()
+		if typ.Len != nil {
+			g.printf("for %s := range %s {\n", i, val)
+		} else {
+			g.printf("enc.List(len(%s), func(enc *fpack.Encoder, %s int) {\n", val, i)
+		}
+		err = g.encode(typ.Elt, val+"["+i+"]", "")
+		if err != nil {
+			return err
+		}
+		if typ.Len != nil {
+			g.printf("}\n")
+		} else {
+			g.printf("})\n")
+		}
+	default:
+		return fmt.Errorf("unsupported type %s", types.ExprString(typ))
+	}
+
+	return nil
+}
+
+func (g *generator) decode(typ ast.Expr, dst, rep string) error {
+	// get representation
+	lenSize, err := checkRep(typ, rep)
+	if err != nil {
+		return err
+	}
+
+	switch typ := typ.(type) {
+	case *ast.Ident:
+		switch typ.Name {
+		case "bool":
+			g.printf("%s = dec.Bool()\n", dst)
+		case "int", "int8", "int16", "int32", "int64":
+			if rep == "varint" {
+				g.printf("%s = %s(dec.VarInt())\n", dst, typ.Name)
+			} else {
+				g.printf("%s = %s(dec.Int(%d))\n", dst, typ.Name, intSize(typ.Name))
+			}
+		case "uint", "uint8", "byte", "uint16", "uint32", "uint64":
+			if rep == "varint" {
+				g.printf("%s = %s(dec.VarUint())\n", dst, typ.Name)
+			} else {
+				g.printf("%s = %s(dec.Uint(%d))\n", dst, typ.Name, intSize(typ.Name))
+			}
+		case "float32":
+			g.printf("%s = dec.Float32()\n", dst)
+		case "float64":
+			g.printf("%s = dec.Float64()\n", dst)
+		case "string":
+			if lenSize > 0 {
+				g.printf("%s = dec.FixString(%d, true)\n", dst, lenSize)
+			} else {
+				g.printf("%s = dec.VarString(true)\n", dst)
+			}
+		default:
+			if basic(typ.Name) {
+				return fmt.Errorf("unsupported type %s", typ.Name)
+			}
+			g.printf("%s.DecodeFpack(dec)\n", dst)
+		}
+	case *ast.SelectorExpr:
+		g.printf("%s.DecodeFpack(dec)\n", dst)
+	case *ast.StarExpr:
+		v := g.variable()
+		g.printf("%s = nil\n", dst)
+		g.printf("dec.Option(func(dec *fpack.Decoder) {\n")
+		g.printf("var %s %s\n", v, types.ExprString(typ.X))
+		err = g.decode(typ.X, v, rep)
+		if err != nil {
+			return err
+		}
+		g.printf("%s = &%s\n", dst, v)
+		g.printf("})\n")
+	case *ast.ArrayType:
+		// handle byte slices
+		if typ.Len == nil && isByte(typ.Elt) {
+			if lenSize > 0 {
+				g.printf("%s = dec.FixBytes(%d, true)\n", dst, lenSize)
+			} else {
+				g.printf("%s = dec.VarBytes(true)\n", dst)
+			}
+			return nil
+		}
+
+		// handle arrays
+		if typ.Len != nil {
+			i := g.variable()
+			g.printf("for %s := range %s {\n", i, dst)
+			err = g.decode(typ.Elt, dst+"["+i+"]", "")
+			if err != nil {
+				return err
+			}
+			g.printf("}\n")
+			return nil
+		}
+
+		// handle slices
+		v := g.variable()
+		g.printf("%s = nil\n", dst)
+		g.printf("dec.List(dec.Length(), func(dec *fpack.Decoder, _ int) error {\n")
+		g.printf("var %s %s\n", v, types.ExprString(typ.Elt))
+		err = g.decode(typ.Elt, v, "")
+		if err != nil {
+			return err
+		}
+		g.printf("%s = append(%s, %s)\n", dst, dst, v)
+		g.printf("return nil\n")
+		g.printf("})\n")
+	default:
+		return fmt.Errorf("unsupported type %s", types.ExprString(typ))
+	}
+
+	return nil
+}
+
+func checkRep(typ ast.Expr, rep string) (int, error) {
+	// pointers pass the representation to their value
+	if _, ok := typ.(*ast.StarExpr); ok {
+		return 0, nil
+	}
+
+	// check representation
+	name, arg, _ := strings.Cut(rep, ":")
+	switch name {
+	case "":
+		return 0, nil
+	case "varint":
+		if id, ok := typ.(*ast.Ident); ok && intSize(id.Name) > 0 {
+			return 0, nil
+		}
+	case "fixstring":
+		lenSize, err := strconv.Atoi(arg)
+		if err != nil || (lenSize != 1 && lenSize != 2 && lenSize != 4 && lenSize != 8) {
+			break
+		}
+		if id, ok := typ.(*ast.Ident); ok && id.Name == "string" {
+			return lenSize, nil
+		}
+		if at, ok := typ.(*ast.ArrayType); ok && at.Len == nil && isByte(at.Elt) {
+			return lenSize, nil
+		}
+	}
+
+	return 0, fmt.Errorf("invalid tag %q on %s", rep, types.ExprString(typ))
+}
+
+func parseTag(tag string) (string, int, error) {
+	// parse options
+	rep, order := "", -1
+	for _, opt := range strings.Split(tag, ",") {
+		if strings.HasPrefix(opt, "order:") {
+			n, err := strconv.Atoi(opt[6:])
+			if err != nil || n < 0 || order >= 0 {
+				return "", 0, fmt.Errorf("invalid tag %q", tag)
+			}
+			order = n
+		} else if rep == "" {
+			rep = opt
+		} else {
+			return "", 0, fmt.Errorf("invalid tag %q", tag)
+		}
+	}
+
+	return rep, order, nil
+}
+
+func intSize(name string) int {
+	switch name {
+	case "int8", "uint8", "byte":
+		return 1
+	case "int16", "uint16":
+		return 2
+	case "int32", "uint32":
+		return 4
+	case "int", "int64", "uint", "uint64":
+		return 8
+	default:
+		return 0
+	}
+}
+
+func isByte(typ ast.Expr) bool {
+	id, ok := typ.(*ast.Ident)
+	return ok && (id.Name == "byte" || id.Name == "uint8")
+}
+
+func basic(name string) bool {
+	return types.Universe.Lookup(name) != nil
+}
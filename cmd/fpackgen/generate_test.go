@@ -0,0 +1,72 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerate(t *testing.T) {
+	src, err := os.ReadFile("internal/example/example.go")
+	assert.NoError(t, err)
+
+	golden, err := os.ReadFile("internal/example/example_fpack.go")
+	assert.NoError(t, err)
+
+	out, err := Generate("example.go", src, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, string(golden), string(out))
+}
+
+func TestGenerateTypes(t *testing.T) {
+	src := []byte("package foo\n\ntype Foo struct {\n\tBar uint16\n}\n")
+
+	out, err := Generate("foo.go", src, []string{"Foo"})
+	assert.NoError(t, err)
+	assert.Contains(t, string(out), "func (v *Foo) EncodeFpack(enc *fpack.Encoder) {\n\tenc.Uint(uint64(v.Bar), 2)\n}")
+	assert.Contains(t, string(out), "func (v *Foo) DecodeFpack(dec *fpack.Decoder) {\n\tv.Bar = uint16(dec.Uint(2))\n}")
+
+	_, err = Generate("foo.go", src, []string{"Bar"})
+	assert.EqualError(t, err, "struct type Bar not found")
+}
+
+func TestGenerateErrors(t *testing.T) {
+	for _, item := range []struct {
+		field string
+		err   string
+	}{
+		{
+			field: "Foo map[string]int",
+			err:   "Foo: Foo: unsupported type map[string]int",
+		},
+		{
+			field: "Foo complex64",
+			err:   "Foo: Foo: unsupported type complex64",
+		},
+		{
+			field: "Foo string `fpack:\"varint\"`",
+			err:   "Foo: Foo: invalid tag \"varint\" on string",
+		},
+		{
+			field: "Foo []int `fpack:\"fixstring:2\"`",
+			err:   "Foo: Foo: invalid tag \"fixstring:2\" on []int",
+		},
+		{
+			field: "Foo int `fpack:\"order:1,order:2\"`",
+			err:   "Foo: invalid tag \"order:1,order:2\"",
+		},
+		{
+			field: "Foo int `fpack:\"order:1\"`\n\tBar int `fpack:\"order:1\"`",
+			err:   "Foo: duplicate order 1",
+		},
+		{
+			field: "Bar",
+			err:   "Foo: embedded field Bar not supported",
+		},
+	} {
+		src := []byte("package foo\n\ntype Foo struct {\n\t" + item.field + "\n}\n")
+		_, err := Generate("foo.go", src, []string{"Foo"})
+		assert.EqualError(t, err, item.err, item.field)
+	}
+}
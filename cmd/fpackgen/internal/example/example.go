@@ -0,0 +1,51 @@
+// Package example is used to test the code generated by fpackgen.
+package example
+
+//go:generate go run github.com/256dpi/fpack/cmd/fpackgen
+
+// Point is a simple annotated struct.
+//
+//fpack:generate
+type Point struct {
+	X, Y int32
+}
+
+// Item is a nested struct.
+//
+//fpack:generate
+type Item struct {
+	Name  string `fpack:"fixstring:1"`
+	Score int64  `fpack:"varint"`
+	Data  []byte
+	Next  *Item
+}
+
+// Record uses all supported field types.
+//
+//fpack:generate
+type Record struct {
+	ID      uint64 `fpack:"order:0"`
+	Flag    bool   `fpack:"order:1"`
+	Int     int
+	Int8    int8
+	Int16   int16 `fpack:"varint"`
+	Uint    uint
+	Uint8   uint8
+	Uint32  uint32 `fpack:"varint"`
+	Float32 float32
+	Float64 float64
+	String  string
+	Bytes   []byte `fpack:"fixstring:2"`
+	Array   [2]uint16
+	Points  []Point
+	Matrix  [][]int8
+	Items   []*Item
+	Ptr     *int32 `fpack:"varint"`
+	Skip    string `fpack:"-"`
+	skip    string
+}
+
+// Ignored is not annotated.
+type Ignored struct {
+	Foo string
+}
@@ -0,0 +1,63 @@
+package example
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/256dpi/fpack"
+)
+
+func TestRoundTrip(t *testing.T) {
+	num := int32(-7)
+	record := Record{
+		ID:      1,
+		Flag:    true,
+		Int:     -2,
+		Int8:    -3,
+		Int16:   -300,
+		Uint:    4,
+		Uint8:   5,
+		Uint32:  600,
+		Float32: 1.5,
+		Float64: 2.5,
+		String:  "foo",
+		Bytes:   []byte("bar"),
+		Array:   [2]uint16{7, 8},
+		Points:  []Point{{X: 1, Y: 2}, {X: -1, Y: -2}},
+		Matrix:  [][]int8{{1, 2}, {}, {3}},
+		Items: []*Item{
+			{Name: "a", Score: -1, Data: []byte("x"), Next: &Item{Name: "b"}},
+			nil,
+		},
+		Ptr: &num,
+	}
+
+	buf, _, err := fpack.Encode(nil, func(enc *fpack.Encoder) error {
+		record.EncodeFpack(enc)
+		return nil
+	})
+	assert.NoError(t, err)
+
+	ref, err := fpack.Marshal(record)
+	assert.NoError(t, err)
+	assert.Equal(t, ref, buf)
+
+	var out Record
+	err = fpack.Decode(buf, func(dec *fpack.Decoder) error {
+		out.DecodeFpack(dec)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []*Item{{Name: "a", Score: -1, Data: []byte("x"), Next: &Item{Name: "b", Data: []byte{}}}, nil}, out.Items)
+	out.Items = record.Items
+	assert.Equal(t, [][]int8{{1, 2}, nil, {3}}, out.Matrix)
+	out.Matrix = record.Matrix
+	assert.Equal(t, record, out)
+
+	err = fpack.Decode(buf[:len(buf)-1], func(dec *fpack.Decoder) error {
+		out.DecodeFpack(dec)
+		return nil
+	})
+	assert.ErrorIs(t, err, fpack.ErrBufferTooShort)
+}
@@ -0,0 +1,70 @@
+// Command fpackgen generates fpack encoding and decoding methods for struct
+// types.
+//
+// The command parses the provided Go file, or the file set by go generate, and
+// generates EncodeFpack and DecodeFpack methods for all struct types annotated
+// with a "//fpack:generate" comment or listed using the -type flag. Fields are
+// encoded like the reflection based fpack.Marshal function and honor the same
+// `fpack` struct tags. Named types other than the basic types must implement
+// the generated methods themselves.
+//
+//	//go:generate fpackgen
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+var typeNames = flag.String("type", "", "comma separated list of type names")
+var output = flag.String("output", "", "output file name (default <file>_fpack.go)")
+
+func main() {
+	// parse flags
+	flag.Parse()
+
+	// get file
+	file := os.Getenv("GOFILE")
+	if flag.NArg() > 0 {
+		file = flag.Arg(0)
+	}
+	if file == "" {
+		fmt.Fprintln(os.Stderr, "fpackgen: missing file")
+		os.Exit(2)
+	}
+
+	// get types
+	var types []string
+	if *typeNames != "" {
+		types = strings.Split(*typeNames, ",")
+	}
+
+	// read file
+	src, err := os.ReadFile(file)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "fpackgen:", err)
+		os.Exit(1)
+	}
+
+	// generate
+	out, err := Generate(file, src, types)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "fpackgen:", err)
+		os.Exit(1)
+	}
+
+	// determine output
+	name := *output
+	if name == "" {
+		name = strings.TrimSuffix(file, ".go") + "_fpack.go"
+	}
+
+	// write file
+	err = os.WriteFile(name, out, 0644)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "fpackgen:", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,74 @@
+package fpack
+
+// Codec bundles the encoding and decoding functions of a type.
+type Codec[T any] struct {
+	Encode func(enc *Encoder, v T)
+	Decode func(dec *Decoder, v *T)
+}
+
+// Marshal will encode the provided value using Encode.
+func (c Codec[T]) Marshal(pool *Pool, v T) ([]byte, Ref, error) {
+	return Encode(pool, func(enc *Encoder) error {
+		c.Encode(enc, v)
+		return nil
+	})
+}
+
+// Unmarshal will decode a value from the provided buffer using Decode.
+func (c Codec[T]) Unmarshal(buf []byte) (T, error) {
+	// decode value
+	var v T
+	err := Decode(buf, func(dec *Decoder) error {
+		c.Decode(dec, &v)
+		return nil
+	})
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+
+	return v, nil
+}
+
+// SliceCodec returns a codec for slices of the provided codec's type. The
+// slices are encoded as lists and decoding sets ErrListTooLong if the count
+// exceeds the specified maximum.
+func SliceCodec[T any](c Codec[T], maxLen int) Codec[[]T] {
+	return Codec[[]T]{
+		Encode: func(enc *Encoder, v []T) {
+			enc.List(len(v), func(enc *Encoder, i int) {
+				c.Encode(enc, v[i])
+			})
+		},
+		Decode: func(dec *Decoder, v *[]T) {
+			var list []T
+			dec.List(maxLen, func(dec *Decoder, i int) error {
+				var item T
+				c.Decode(dec, &item)
+				list = append(list, item)
+				return nil
+			})
+			*v = list
+		},
+	}
+}
+
+// OptionCodec returns a codec for optional values of the provided codec's type.
+// Nil pointers are encoded as absent values.
+func OptionCodec[T any](c Codec[T]) Codec[*T] {
+	return Codec[*T]{
+		Encode: func(enc *Encoder, v *T) {
+			enc.Option(v != nil, func(enc *Encoder) {
+				c.Encode(enc, *v)
+			})
+		},
+		Decode: func(dec *Decoder, v **T) {
+			*v = nil
+			dec.Option(func(dec *Decoder) {
+				var item T
+				c.Decode(dec, &item)
+				*v = &item
+			})
+		},
+	}
+}
@@ -0,0 +1,81 @@
+package fpack
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type point struct {
+	X, Y int32
+}
+
+var pointCodec = Codec[point]{
+	Encode: func(enc *Encoder, v point) {
+		enc.Int32(v.X)
+		enc.Int32(v.Y)
+	},
+	Decode: func(dec *Decoder, v *point) {
+		v.X = dec.Int32()
+		v.Y = dec.Int32()
+	},
+}
+
+func TestCodec(t *testing.T) {
+	buf, ref, err := pointCodec.Marshal(nil, point{X: 1, Y: -1})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0, 0, 0, 1, 0xFF, 0xFF, 0xFF, 0xFF}, buf)
+	ref.Release()
+
+	p, err := pointCodec.Unmarshal(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, point{X: 1, Y: -1}, p)
+
+	p, err = pointCodec.Unmarshal(buf[:7])
+	assert.ErrorIs(t, err, ErrBufferTooShort)
+	assert.Zero(t, p)
+
+	p, err = pointCodec.Unmarshal(append(buf, 0))
+	assert.ErrorIs(t, err, ErrRemainingBytes)
+	assert.Zero(t, p)
+}
+
+func TestSliceCodec(t *testing.T) {
+	codec := SliceCodec(pointCodec, 2)
+
+	buf, _, err := codec.Marshal(nil, []point{{X: 1}, {Y: 2}})
+	assert.NoError(t, err)
+	assert.Len(t, buf, 17)
+
+	list, err := codec.Unmarshal(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, []point{{X: 1}, {Y: 2}}, list)
+
+	buf, _, err = codec.Marshal(nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0}, buf)
+
+	list, err = codec.Unmarshal(buf)
+	assert.NoError(t, err)
+	assert.Empty(t, list)
+
+	list, err = codec.Unmarshal([]byte{3})
+	assert.ErrorIs(t, err, ErrListTooLong)
+	assert.Nil(t, list)
+}
+
+func TestOptionCodec(t *testing.T) {
+	codec := SliceCodec(OptionCodec(pointCodec), 10)
+
+	buf, _, err := codec.Marshal(nil, []*point{{X: 1}, nil})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{2, 1, 0, 0, 0, 1, 0, 0, 0, 0, 0}, buf)
+
+	list, err := codec.Unmarshal(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, []*point{{X: 1}, nil}, list)
+
+	list, err = codec.Unmarshal([]byte{1, 2})
+	assert.ErrorIs(t, err, ErrInvalidOption)
+	assert.Nil(t, list)
+}
@@ -0,0 +1,179 @@
+package fpack
+
+import "errors"
+
+// ErrInvalidBlock is returned if a decompressed block has an unexpected length.
+var ErrInvalidBlock = errors.New("invalid block")
+
+// Compressor is used to compress and decompress blocks. The functions should
+// append to the provided destination slice and return the result.
+type Compressor interface {
+	Compress(dst, src []byte) ([]byte, error)
+	Decompress(dst, src []byte) ([]byte, error)
+}
+
+type block struct {
+	len int
+	buf []byte
+}
+
+// CompressedBlock writes the data written by the provided function as a
+// compressed block. The block consists of a variable uncompressed length, a
+// variable compressed length and the compressed bytes. The block is compressed
+// while counting and cached for the following write pass.
+func (e *Encoder) CompressedBlock(c Compressor, fn func(enc *Encoder)) {
+	// skip if errored
+	if e.err != nil {
+		return
+	}
+
+	// get cached or compress block
+	var blk block
+	if e.buf != nil && e.cmi < len(e.cmp) {
+		blk = e.cmp[e.cmi]
+		e.cmi++
+	} else {
+		blk = e.compress(c, fn)
+		if e.err != nil {
+			return
+		}
+		if e.buf == nil {
+			e.cmp = append(e.cmp, blk)
+		}
+	}
+
+	// write block
+	e.VarUint(uint64(blk.len))
+	e.VarBytes(blk.buf)
+}
+
+func (e *Encoder) compress(c Compressor, fn func(enc *Encoder)) block {
+	// borrow
+	sub := encoderPool.Get().(*Encoder)
+
+	// recycle
+	defer func() {
+		sub.Reset(nil)
+		encoderPool.Put(sub)
+	}()
+
+	// count
+	sub.bo = e.bo
+	fn(sub)
+	if sub.err != nil {
+		e.err = sub.err
+		return block{}
+	}
+
+	// encode
+	buf, ref := Global().Borrow(sub.len, false)
+	defer ref.Release()
+	sub.Reset(buf)
+	sub.bo = e.bo
+	fn(sub)
+	if sub.err != nil {
+		e.err = sub.err
+		return block{}
+	}
+
+	// compress
+	res, err := c.Compress(nil, buf)
+	if err != nil {
+		e.err = err
+		return block{}
+	}
+
+	return block{
+		len: len(buf),
+		buf: res,
+	}
+}
+
+// CompressedBlock reads a compressed block and calls the provided function
+// with a decoder for the decompressed data. The function must consume all
+// data, otherwise ErrRemainingBytes is set. Uncompressed lengths above the
+// specified maximum result in ErrLengthLimit and a negative maximum results in
+// ErrInvalidLength. The decompressed data is allocated using the arena if
+// configured.
+func (d *Decoder) CompressedBlock(c Compressor, maxLen int, fn func(dec *Decoder) error) {
+	// read length
+	length := d.varuint("compressedblock")
+	if d.err != nil {
+		return
+	}
+
+	// check length
+	if maxLen < 0 {
+		d.fail(ErrInvalidLength, "compressedblock", 0)
+		return
+	} else if length > uint64(maxLen) {
+		d.fail(ErrLengthLimit, "compressedblock", 0)
+		return
+	}
+	d.length("compressedblock", length)
+	if d.err != nil {
+		return
+	}
+
+	// read block
+	data := d.bytes("compressedblock", d.length("compressedblock", d.varuint("compressedblock")), false)
+	if d.err != nil {
+		return
+	}
+
+	// allocate buffer
+	var buf []byte
+	if d.arn != nil {
+		buf = d.arn.Get(int(length), false)
+	} else {
+		buf = make([]byte, length)
+	}
+
+	// decompress
+	buf, err := c.Decompress(buf[:0], data)
+	if err != nil {
+		d.failAt(err, "compressedblock", d.Offset()-len(data), len(data))
+		return
+	} else if uint64(len(buf)) != length {
+		d.failAt(ErrInvalidBlock, "compressedblock", d.Offset()-len(data), len(data))
+		return
+	}
+
+	// enter block
+	if !d.enter("compressedblock") {
+		return
+	}
+	defer d.leave()
+
+	// borrow
+	sub := decoderPool.Get().(*Decoder)
+	sub.Reset(buf)
+	sub.bo = d.bo
+	sub.arn = d.arn
+	sub.utf = d.utf
+	sub.det = d.det
+	sub.lim = d.lim
+	sub.dep = d.dep
+	sub.max = d.max
+
+	// recycle
+	defer func() {
+		sub.Reset(nil)
+		decoderPool.Put(sub)
+	}()
+
+	// decode
+	err = fn(sub)
+	if err == nil {
+		err = sub.err
+	}
+	if err != nil {
+		d.err = err
+		return
+	}
+
+	// check length
+	if !sub.rem && len(sub.buf) != 0 {
+		d.fail(ErrRemainingBytes, "compressedblock", len(sub.buf))
+	}
+}
@@ -0,0 +1,106 @@
+package fpack
+
+import (
+	"bytes"
+	"compress/flate"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type flateCompressor struct{}
+
+func (flateCompressor) Compress(dst, src []byte) ([]byte, error) {
+	buf := bytes.NewBuffer(dst)
+	w, _ := flate.NewWriter(buf, flate.BestCompression)
+	_, _ = w.Write(src)
+	err := w.Close()
+	return buf.Bytes(), err
+}
+
+func (flateCompressor) Decompress(dst, src []byte) ([]byte, error) {
+	buf := bytes.NewBuffer(dst)
+	_, err := io.Copy(buf, flate.NewReader(bytes.NewReader(src)))
+	return buf.Bytes(), err
+}
+
+func TestCompressedBlock(t *testing.T) {
+	text := strings.Repeat("hello world! ", 100)
+
+	var calls int
+	buf, _, err := Encode(nil, func(enc *Encoder) error {
+		enc.Uint8(42)
+		enc.CompressedBlock(flateCompressor{}, func(enc *Encoder) {
+			calls++
+			enc.VarString(text)
+			enc.Uint16(7)
+		})
+		enc.CompressedBlock(flateCompressor{}, func(enc *Encoder) {
+			enc.String("foo")
+		})
+		enc.Uint8(42)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, calls)
+	assert.Less(t, len(buf), 100)
+
+	var num uint16
+	var str1, str2 string
+	err = Decode(buf, func(dec *Decoder) error {
+		dec.Uint8()
+		dec.CompressedBlock(flateCompressor{}, 2048, func(dec *Decoder) error {
+			str1 = dec.VarString(true)
+			num = dec.Uint16()
+			return nil
+		})
+		dec.CompressedBlock(flateCompressor{}, 2048, func(dec *Decoder) error {
+			str2 = dec.TailString(true)
+			return nil
+		})
+		dec.Uint8()
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, text, str1)
+	assert.Equal(t, uint16(7), num)
+	assert.Equal(t, "foo", str2)
+
+	err = Decode(buf, func(dec *Decoder) error {
+		dec.Uint8()
+		dec.CompressedBlock(flateCompressor{}, 1024, func(dec *Decoder) error {
+			return nil
+		})
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrLengthLimit)
+
+	err = Decode(buf, func(dec *Decoder) error {
+		dec.Uint8()
+		dec.CompressedBlock(flateCompressor{}, -1, func(dec *Decoder) error {
+			return nil
+		})
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrInvalidLength)
+
+	err = Decode(buf, func(dec *Decoder) error {
+		dec.Uint8()
+		dec.CompressedBlock(flateCompressor{}, 2048, func(dec *Decoder) error {
+			dec.VarString(false)
+			return nil
+		})
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrRemainingBytes)
+
+	_, _, err = Encode(nil, func(enc *Encoder) error {
+		enc.CompressedBlock(flateCompressor{}, func(enc *Encoder) {
+			enc.Uint(0, 3)
+		})
+		return nil
+	})
+	assert.Equal(t, ErrInvalidSize, err)
+}
@@ -3,11 +3,13 @@ package fpack
 import (
 	"bytes"
 	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
 	"math"
 	"sync"
 	"time"
-
-	"github.com/tidwall/cast"
+	"unicode/utf8"
 )
 
 var decoderPool = sync.Pool{
@@ -17,13 +19,43 @@ var decoderPool = sync.Pool{
 }
 
 // Decode will decode data using the provided decoding function. The function is
-// run once to decode the data. It will return ErrBufferTooShort if the buffer
-// was not long enough to read all data, ErrRemainingBytes if the provided
-// buffers has not been full consumed or any error returned by the callback.
+// run once to decode the data. Read errors are returned as a DecodeError that
+// wraps the cause like ErrBufferTooShort if the buffer was not long enough to
+// read all data. It will also return a RemainingError if the provided buffers
+// has not been full consumed or any error returned by the callback.
 func Decode(bytes []byte, fn func(dec *Decoder) error) error {
+	_, err := decode(bytes, Options{}, fn)
+	return err
+}
+
+// DecodeString will decode data from the provided string like Decode without
+// copying it. Strings and byte slices that are not cloned alias the memory of
+// the provided string and returned byte slices must therefore not be mutated.
+func DecodeString(str string, fn func(dec *Decoder) error) error {
+	_, err := decode(toBytes(str), Options{}, fn)
+	return err
+}
+
+// DecodeVec will decode data spread across the provided byte slices like
+// Decode. The decoder continues into the next slice when the current one is
+// exhausted. Values that straddle a boundary are stitched into an allocated
+// buffer while all other values are read directly from the slices. Reads that
+// scan for a terminator, like DelString, OrderedString or Netstring, stitch all
+// remaining slices once. Strings and byte slices that are not cloned alias
+// either the provided slices or a stitch buffer and stay valid as long as the
+// provided slices.
+func DecodeVec(bufs [][]byte, fn func(dec *Decoder) error) error {
+	// handle single slice
+	if len(bufs) <= 1 {
+		var buf []byte
+		if len(bufs) == 1 {
+			buf = bufs[0]
+		}
+		return Decode(buf, fn)
+	}
+
 	// borrow
 	dec := decoderPool.Get().(*Decoder)
-	dec.Reset(bytes)
 
 	// recycle
 	defer func() {
@@ -32,30 +64,140 @@ func Decode(bytes []byte, fn func(dec *Decoder) error) error {
 	}()
 
 	// decode
-	err := fn(dec)
+	_, err := dec.decode(nil, Options{}, func(dec *Decoder) error {
+		dec.org = bufs[0]
+		dec.buf = bufs[0]
+		dec.vec = bufs[1:]
+		dec.src = bufs
+		return fn(dec)
+	})
+
+	return err
+}
+
+// DecodePartial will decode data using the provided decoding function like
+// Decode but without requiring the buffer to be fully consumed. It returns the
+// number of consumed bytes, which is also accurate if an error is returned.
+func DecodePartial(bytes []byte, fn func(dec *Decoder) error) (int, error) {
+	return decode(bytes, Options{AllowRemaining: true}, fn)
+}
+
+func decode(bytes []byte, opts Options, fn func(dec *Decoder) error) (int, error) {
+	// borrow
+	dec := decoderPool.Get().(*Decoder)
+
+	// recycle
+	defer func() {
+		dec.Reset(nil)
+		decoderPool.Put(dec)
+	}()
+
+	return dec.decode(bytes, opts, fn)
+}
+
+func (d *Decoder) decode(bytes []byte, opts Options, fn func(dec *Decoder) error) (int, error) {
+	// reset decoder
+	d.Reset(bytes)
+	opts.applyDecoder(d)
+
+	// decode
+	err := fn(d)
 	if err != nil {
-		return err
+		return d.Offset(), err
 	}
 
 	// check error
-	err = dec.Error()
+	err = d.Error()
 	if err != nil {
-		return err
+		return d.Offset(), err
 	}
 
 	// check length
-	if dec.Length() != 0 {
-		return ErrRemainingBytes
+	if !d.rem && d.Length() != 0 {
+		return d.Offset(), d.remaining()
 	}
 
-	return nil
+	return d.Offset(), nil
+}
+
+// MustDecode will decode data using the provided decoding function like Decode.
+// The function cannot return an error, decoder errors and ErrRemainingBytes are
+// still returned.
+func MustDecode(bytes []byte, fn func(dec *Decoder)) error {
+	return Decode(bytes, func(dec *Decoder) error {
+		fn(dec)
+		return nil
+	})
+}
+
+// DecodeError is set by a decoder with detailed errors enabled if a read
+// failed. The offset is the position at which the failed read started, the
+// operation is the name of the called method like "uint32" or "varstring" and
+// the length is the requested number of bytes, or zero if not known upfront.
+// It matches the wrapped error using errors.Is.
+type DecodeError struct {
+	Offset int
+	Op     string
+	Length int
+	Err    error
+}
+
+// Error implements the error interface.
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("%s at offset %d (length %d): %s", e.Op, e.Offset, e.Length, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// RemainingError is returned if the buffer has not been fully consumed. The
+// offset is the position at which decoding stopped, the length is the number
+// of remaining bytes and the preview holds a copy of up to the first sixteen
+// remaining bytes. It matches ErrRemainingBytes using errors.Is.
+type RemainingError struct {
+	Offset  int
+	Length  int
+	Preview []byte
+}
+
+// Error implements the error interface.
+func (e *RemainingError) Error() string {
+	var more string
+	if len(e.Preview) < e.Length {
+		more = "..."
+	}
+	return fmt.Sprintf("%s: %d at offset %d (%x%s)", ErrRemainingBytes, e.Length, e.Offset, e.Preview, more)
+}
+
+// Unwrap returns ErrRemainingBytes.
+func (e *RemainingError) Unwrap() error {
+	return ErrRemainingBytes
 }
 
 // Decoder manages data decoding.
 type Decoder struct {
 	bo  binary.ByteOrder
 	arn *Arena
+	nsl int
+	utf bool
+	rem bool
+	det bool
+	stm bool
+	trc func(op string, offset, length int, value interface{})
+	lim Limits
+	dep int
+	max int
+	gen uint64
+	src [][]byte
+	vec [][]byte
+	vof int
+	off int
+	org []byte
 	buf []byte
+	unr []byte
+	uof int
 	err error
 }
 
@@ -63,6 +205,7 @@ type Decoder struct {
 func NewDecoder(buf []byte) *Decoder {
 	return &Decoder{
 		bo:  binary.BigEndian,
+		org: buf,
 		buf: buf,
 	}
 }
@@ -71,23 +214,140 @@ func NewDecoder(buf []byte) *Decoder {
 func (d *Decoder) Reset(buf []byte) {
 	d.bo = binary.BigEndian
 	d.arn = nil
+	d.nsl = 0
+	d.utf = false
+	d.rem = false
+	d.det = false
+	d.stm = false
+	d.trc = nil
+	d.lim = Limits{}
+	d.dep = 0
+	d.max = 0
+	d.gen++
+	d.src = nil
+	d.vec = nil
+	d.vof = 0
+	d.off = 0
+	d.org = buf
 	d.buf = buf
+	d.unr = nil
+	d.uof = 0
 	d.err = nil
 }
 
+// Decode will decode data like the package function Decode but using the
+// decoder instead of a pooled one. The decoder is reset before and after
+// decoding.
+func (d *Decoder) Decode(bytes []byte, fn func(dec *Decoder) error) error {
+	// ensure reset
+	defer d.Reset(nil)
+
+	// decode
+	_, err := d.decode(bytes, Options{}, fn)
+
+	return err
+}
+
 // UseLittleEndian will set the used binary byte order to little endian.
 func (d *Decoder) UseLittleEndian() {
 	d.bo = binary.LittleEndian
 }
 
+// SetByteOrder will set the used binary byte order.
+func (d *Decoder) SetByteOrder(bo binary.ByteOrder) {
+	d.bo = bo
+}
+
 // UseArena will use the specified arena for string and bytes cloning.
 func (d *Decoder) UseArena(arena *Arena) {
 	d.arn = arena
 }
 
+// ValidateUTF8 will enable or disable UTF-8 validation for all subsequent
+// string reads using the String, FixString, VarString, DelString, DelStringAny
+// and TailString methods. Invalid strings result in ErrInvalidUTF8.
+func (d *Decoder) ValidateUTF8(validate bool) {
+	d.utf = validate
+}
+
+// AllowRemaining will allow the buffer to not be fully consumed when the
+// decoding function returns. This is useful to ignore unknown trailing data
+// written by newer versions of a format.
+func (d *Decoder) AllowRemaining() {
+	d.rem = true
+}
+
+// DetailErrors will enable or disable detailed errors. If enabled, read errors
+// like ErrBufferTooShort are set as a DecodeError that carries the offset,
+// operation and requested length of the failed read. Detailed errors allocate
+// and are therefore disabled by default for decoders created with NewDecoder.
+// Decode, DecodeWith and the other package functions enable them.
+func (d *Decoder) DetailErrors(detail bool) {
+	d.det = detail
+}
+
+// Trace will set a function that is called for every primitive read with the
+// operation name, the starting offset and the length of the read. Composite
+// reads like VarString report their underlying reads. Reset clears the
+// function.
+func (d *Decoder) Trace(fn func(op string, offset, length int)) {
+	// unset if nil
+	if fn == nil {
+		d.trc = nil
+		return
+	}
+
+	// set function
+	d.trc = func(op string, offset, length int, _ interface{}) {
+		fn(op, offset, length)
+	}
+}
+
+// SetMaxLength will set the maximum length announced by the length prefix of
+// FixString, FixBytes, VarString, VarBytes, HexBytes and the uncompressed
+// length of CompressedBlock. Longer lengths result in ErrLengthLimit before any
+// data is read. A zero or negative maximum disables the check. Reset clears
+// the maximum.
+func (d *Decoder) SetMaxLength(max int) {
+	d.max = max
+}
+
 // Length returns the remaining length of the buffer.
 func (d *Decoder) Length() int {
-	return len(d.buf)
+	// add following slices
+	length := len(d.buf)
+	if len(d.vec) > 0 {
+		length -= d.vof
+		for _, buf := range d.vec {
+			length += len(buf)
+		}
+	}
+
+	return length
+}
+
+// RestLen returns the remaining length of the buffer like Length.
+func (d *Decoder) RestLen() int {
+	return d.Length()
+}
+
+// Rest returns the remaining bytes without consuming them or nil if errored.
+// The returned slice aliases the source byte slice and must not be modified.
+func (d *Decoder) Rest() []byte {
+	// check error
+	if d.err != nil {
+		return nil
+	}
+
+	// stitch following slices
+	d.need(d.Length())
+
+	return d.buf
+}
+
+// Offset returns the number of bytes consumed since the last reset.
+func (d *Decoder) Offset() int {
+	return d.off + len(d.org) - len(d.buf)
 }
 
 // Error will return the current error.
@@ -95,63 +355,482 @@ func (d *Decoder) Error() error {
 	return d.err
 }
 
+func (d *Decoder) fail(err error, op string, length int) {
+	d.failAt(err, op, d.Offset(), length)
+}
+
+// failAt will set the provided error like fail for a read that started at the
+// specified offset. It is used if a value is rejected after being consumed.
+func (d *Decoder) failAt(err error, op string, offset, length int) {
+	// handle incomplete stream data
+	if d.stm && err == ErrBufferTooShort {
+		err = ErrNeedMore
+	}
+
+	// set plain error
+	if !d.det {
+		d.err = err
+		return
+	}
+
+	// set detailed error
+	d.err = &DecodeError{
+		Offset: offset,
+		Op:     op,
+		Length: length,
+		Err:    err,
+	}
+}
+
+// need will ensure the buffer holds the specified amount of bytes when decoding
+// from multiple slices and returns whether it does.
+func (d *Decoder) need(num int) bool {
+	return len(d.buf) >= num || d.more(num)
+}
+
+func (d *Decoder) more(num int) bool {
+	// check slices
+	if len(d.vec) == 0 {
+		return false
+	}
+
+	// forget unread byte as the buffer changes
+	d.unr = nil
+
+	// continue with next slice if exhausted
+	for len(d.buf) == 0 && len(d.vec) > 0 {
+		next := d.vec[0][d.vof:]
+		d.vec = d.vec[1:]
+		d.vof = 0
+		d.off = d.Offset()
+		d.org = next
+		d.buf = next
+	}
+	if len(d.buf) >= num {
+		return true
+	}
+
+	// check length
+	if d.Length() < num {
+		return false
+	}
+
+	// stitch bytes from following slices
+	buf := make([]byte, len(d.buf), num)
+	copy(buf, d.buf)
+	for len(buf) < num {
+		next := d.vec[0][d.vof:]
+		if len(next) > num-len(buf) {
+			next = next[:num-len(buf)]
+			d.vof += len(next)
+		} else {
+			d.vec = d.vec[1:]
+			d.vof = 0
+		}
+		buf = append(buf, next...)
+	}
+
+	// set buffer
+	d.off = d.Offset()
+	d.org = buf
+	d.buf = buf
+
+	return true
+}
+
+func (d *Decoder) upto(num int) {
+	// stitch up to the specified amount of bytes
+	if len(d.buf) < num && len(d.vec) > 0 {
+		if length := d.Length(); length < num {
+			num = length
+		}
+		d.more(num)
+	}
+}
+
+func (d *Decoder) remaining() error {
+	// stitch following slices
+	d.need(d.Length())
+
+	// get preview
+	preview := d.buf
+	if len(preview) > 16 {
+		preview = preview[:16]
+	}
+
+	return &RemainingError{
+		Offset:  d.Offset(),
+		Length:  len(d.buf),
+		Preview: append([]byte(nil), preview...),
+	}
+}
+
 // Remaining returns whether more bytes can be decoded.
 func (d *Decoder) Remaining() bool {
-	return len(d.buf) > 0 && d.err == nil
+	return d.err == nil && d.Length() > 0
+}
+
+// Checkpoint is an opaque decoder position returned by Mark.
+type Checkpoint struct {
+	dec *Decoder
+	gen uint64
+	vec [][]byte
+	vof int
+	off int
+	org []byte
+	buf []byte
+	err error
+}
+
+// Mark returns a checkpoint of the current position and error state.
+func (d *Decoder) Mark() Checkpoint {
+	return Checkpoint{
+		dec: d,
+		gen: d.gen,
+		vec: d.vec,
+		vof: d.vof,
+		off: d.off,
+		org: d.org,
+		buf: d.buf,
+		err: d.err,
+	}
+}
+
+// Rewind restores the position and error state of the provided checkpoint.
+// It sets ErrInvalidCheckpoint if the checkpoint was not created by the decoder
+// since its last reset.
+func (d *Decoder) Rewind(cp Checkpoint) {
+	// check checkpoint
+	if cp.dec != d || cp.gen != d.gen {
+		if d.err == nil {
+			d.fail(ErrInvalidCheckpoint, "rewind", 0)
+		}
+		return
+	}
+
+	// restore
+	d.unr = nil
+	d.vec = cp.vec
+	d.vof = cp.vof
+	d.off = cp.off
+	d.org = cp.org
+	d.buf = cp.buf
+	d.err = cp.err
 }
 
 // Skip the specified amount of bytes.
 func (d *Decoder) Skip(num int) {
+	d.skip("skip", num)
+}
+
+func (d *Decoder) skip(op string, num int) {
+	// skip if errored
+	if d.err != nil {
+		return
+	}
+
+	// check size
+	if num < 0 {
+		d.fail(ErrInvalidSize, op, num)
+		return
+	}
+
+	// check length
+	if !d.need(num) {
+		d.fail(ErrBufferTooShort, op, num)
+		return
+	}
+
+	// trace
+	if d.trc != nil {
+		d.trc("skip", d.Offset(), num, nil)
+	}
+
+	// slice
+	d.buf = d.buf[num:]
+}
+
+// Discard consumes the specified amount of bytes like Skip. It is meant to
+// express that the bytes are deliberately ignored.
+func (d *Decoder) Discard(num int) {
 	// skip if errored
 	if d.err != nil {
 		return
 	}
 
+	// check size
+	if num < 0 {
+		d.fail(ErrInvalidSize, "discard", num)
+		return
+	}
+
 	// check length
-	if len(d.buf) < num {
-		d.err = ErrBufferTooShort
+	if !d.need(num) {
+		d.fail(ErrBufferTooShort, "discard", num)
 		return
 	}
 
+	// trace
+	if d.trc != nil {
+		d.trc("discard", d.Offset(), num, nil)
+	}
+
 	// slice
 	d.buf = d.buf[num:]
 }
 
+// DiscardRest consumes all remaining bytes without returning them.
+func (d *Decoder) DiscardRest() {
+	d.Discard(d.Length())
+}
+
+// SkipPadding consumes the specified amount of bytes written by Fill and sets
+// ErrInvalidPadding if any of them does not equal the provided byte. Use Skip
+// or Discard to consume padding without verification.
+func (d *Decoder) SkipPadding(num int, b byte) {
+	// skip if errored
+	if d.err != nil {
+		return
+	}
+
+	// check size
+	if num < 0 {
+		d.fail(ErrInvalidSize, "skippadding", num)
+		return
+	}
+
+	// check length
+	if !d.need(num) {
+		d.fail(ErrBufferTooShort, "skippadding", num)
+		return
+	}
+
+	// verify bytes
+	for _, c := range d.buf[:num] {
+		if c != b {
+			d.fail(ErrInvalidPadding, "skippadding", num)
+			return
+		}
+	}
+
+	// trace
+	if d.trc != nil {
+		d.trc("skippadding", d.Offset(), num, nil)
+	}
+
+	// slice
+	d.buf = d.buf[num:]
+}
+
+// Limit calls the provided function with the decoder restricted to the next
+// specified amount of bytes. The function must consume all bytes of the window,
+// otherwise ErrRemainingBytes is set. Any error returned by the function is
+// set on the decoder. Afterwards, decoding continues after the window.
+func (d *Decoder) Limit(length int, fn func(dec *Decoder) error) {
+	// skip if errored
+	if d.err != nil {
+		return
+	}
+
+	// check size
+	if length < 0 {
+		d.fail(ErrInvalidSize, "limit", length)
+		return
+	}
+
+	// check length
+	if !d.need(length) {
+		d.fail(ErrBufferTooShort, "limit", length)
+		return
+	}
+
+	// restrict buffer and hide following slices
+	rest := d.buf[length:]
+	vec, vof := d.vec, d.vof
+	d.buf = d.buf[:length]
+	d.org = d.org[:len(d.org)-len(rest)]
+	d.vec = nil
+
+	// disable stream mode as the window is complete
+	stm := d.stm
+	d.stm = false
+
+	// decode window
+	if d.enter("limit") {
+		err := fn(d)
+		if err != nil && d.err == nil {
+			d.err = err
+		}
+		d.leave()
+	}
+
+	// restore stream mode
+	d.stm = stm
+
+	// check length
+	if d.err == nil && len(d.buf) != 0 {
+		d.fail(ErrRemainingBytes, "limit", len(d.buf))
+	}
+
+	// restore buffer and following slices
+	d.buf = rest
+	d.org = d.org[:len(d.org)+len(rest)]
+	d.vec, d.vof = vec, vof
+}
+
 // Bool reads a boolean.
 func (d *Decoder) Bool() bool {
-	return d.Uint8() == 1
+	return d.uint("bool", 1) == 1
+}
+
+// StrictBool reads a boolean and sets ErrInvalidBool if the byte is neither 0
+// nor 1.
+func (d *Decoder) StrictBool() bool {
+	// read byte
+	b := d.uint("strictbool", 1)
+	if d.err != nil {
+		return false
+	}
+
+	// check byte
+	if b > 1 {
+		d.failAt(ErrInvalidBool, "strictbool", d.Offset()-1, 1)
+		return false
+	}
+
+	return b == 1
+}
+
+// Option reads an optional value. The provided function is only called if the
+// value is present. It returns whether the value was present. A presence byte
+// other than 0 or 1 will result in ErrInvalidOption.
+func (d *Decoder) Option(fn func(dec *Decoder)) bool {
+	// read presence
+	flag := d.uint("option", 1)
+	if d.err != nil {
+		return false
+	}
+
+	// check presence
+	switch flag {
+	case 0:
+		return false
+	case 1:
+	default:
+		d.failAt(ErrInvalidOption, "option", d.Offset()-1, 1)
+		return false
+	}
+
+	// decode value
+	if !d.enter("option") {
+		return false
+	}
+	fn(d)
+	d.leave()
+
+	return true
 }
 
 // Int8 reads a one byte signed integer (two's complement).
 func (d *Decoder) Int8() int8 {
-	return int8(d.Int(1))
+	return int8(d.int("int8", 1))
 }
 
 // Int16 reads a two byte signed integer (two's complement).
 func (d *Decoder) Int16() int16 {
-	return int16(d.Int(2))
+	return int16(d.int("int16", 2))
 }
 
 // Int32 reads a four byte signed integer (two's complement).
 func (d *Decoder) Int32() int32 {
-	return int32(d.Int(4))
+	return int32(d.int("int32", 4))
 }
 
 // Int64 reads an eight byte signed integer (two's complement).
 func (d *Decoder) Int64() int64 {
-	return d.Int(8)
+	return d.int("int64", 8)
+}
+
+// Int16LE reads a two byte signed integer (two's complement) in little
+// endian byte order.
+func (d *Decoder) Int16LE() int16 {
+	bo := d.bo
+	d.bo = binary.LittleEndian
+	num := d.int("int16le", 2)
+	d.bo = bo
+
+	return int16(num)
+}
+
+// Int16BE reads a two byte signed integer (two's complement) in big
+// endian byte order.
+func (d *Decoder) Int16BE() int16 {
+	bo := d.bo
+	d.bo = binary.BigEndian
+	num := d.int("int16be", 2)
+	d.bo = bo
+
+	return int16(num)
+}
+
+// Int32LE reads a four byte signed integer (two's complement) in little
+// endian byte order.
+func (d *Decoder) Int32LE() int32 {
+	bo := d.bo
+	d.bo = binary.LittleEndian
+	num := d.int("int32le", 4)
+	d.bo = bo
+
+	return int32(num)
+}
+
+// Int32BE reads a four byte signed integer (two's complement) in big
+// endian byte order.
+func (d *Decoder) Int32BE() int32 {
+	bo := d.bo
+	d.bo = binary.BigEndian
+	num := d.int("int32be", 4)
+	d.bo = bo
+
+	return int32(num)
+}
+
+// Int64LE reads an eight byte signed integer (two's complement) in little
+// endian byte order.
+func (d *Decoder) Int64LE() int64 {
+	bo := d.bo
+	d.bo = binary.LittleEndian
+	num := d.int("int64le", 8)
+	d.bo = bo
+
+	return int64(num)
+}
+
+// Int64BE reads an eight byte signed integer (two's complement) in big
+// endian byte order.
+func (d *Decoder) Int64BE() int64 {
+	bo := d.bo
+	d.bo = binary.BigEndian
+	num := d.int("int64be", 8)
+	d.bo = bo
+
+	return int64(num)
 }
 
 // Int read a one, two, four or eight byte signed integer (two's complement).
 func (d *Decoder) Int(size int) int64 {
+	return d.int("int", size)
+}
+
+func (d *Decoder) int(op string, size int) int64 {
 	// skip if errored
 	if d.err != nil {
 		return 0
 	}
 
 	// check length
-	if len(d.buf) < size {
-		d.err = ErrBufferTooShort
+	if !d.need(size) {
+		d.fail(ErrBufferTooShort, op, size)
 		return 0
 	}
 
@@ -167,83 +846,375 @@ func (d *Decoder) Int(size int) int64 {
 	case 8:
 		i = int64(d.bo.Uint64(d.buf))
 	default:
-		d.err = ErrInvalidSize
+		d.fail(ErrInvalidSize, op, size)
 		return 0
 	}
 
+	// trace
+	if d.trc != nil {
+		d.trc("int", d.Offset(), size, i)
+	}
+
 	// slice
 	d.buf = d.buf[size:]
 
 	return i
 }
 
-// Uint8 reads a one byte unsigned integer.
-func (d *Decoder) Uint8() uint8 {
-	return uint8(d.Uint(1))
+// Uint8 reads a one byte unsigned integer.
+func (d *Decoder) Uint8() uint8 {
+	return uint8(d.uint("uint8", 1))
+}
+
+// Uint16 reads a two byte unsigned integer.
+func (d *Decoder) Uint16() uint16 {
+	return uint16(d.uint("uint16", 2))
+}
+
+// Uint32 reads a four byte unsigned integer.
+func (d *Decoder) Uint32() uint32 {
+	return uint32(d.uint("uint32", 4))
+}
+
+// Uint64 reads an eight byte unsigned integer.
+func (d *Decoder) Uint64() uint64 {
+	return d.uint("uint64", 8)
+}
+
+// Uint16LE reads a two byte unsigned integer in little endian byte order.
+func (d *Decoder) Uint16LE() uint16 {
+	bo := d.bo
+	d.bo = binary.LittleEndian
+	num := d.uint("uint16le", 2)
+	d.bo = bo
+
+	return uint16(num)
+}
+
+// Uint16BE reads a two byte unsigned integer in big endian byte order.
+func (d *Decoder) Uint16BE() uint16 {
+	bo := d.bo
+	d.bo = binary.BigEndian
+	num := d.uint("uint16be", 2)
+	d.bo = bo
+
+	return uint16(num)
+}
+
+// Uint32LE reads a four byte unsigned integer in little endian byte order.
+func (d *Decoder) Uint32LE() uint32 {
+	bo := d.bo
+	d.bo = binary.LittleEndian
+	num := d.uint("uint32le", 4)
+	d.bo = bo
+
+	return uint32(num)
+}
+
+// Uint32BE reads a four byte unsigned integer in big endian byte order.
+func (d *Decoder) Uint32BE() uint32 {
+	bo := d.bo
+	d.bo = binary.BigEndian
+	num := d.uint("uint32be", 4)
+	d.bo = bo
+
+	return uint32(num)
+}
+
+// Uint64LE reads an eight byte unsigned integer in little endian byte order.
+func (d *Decoder) Uint64LE() uint64 {
+	bo := d.bo
+	d.bo = binary.LittleEndian
+	num := d.uint("uint64le", 8)
+	d.bo = bo
+
+	return uint64(num)
+}
+
+// Uint64BE reads an eight byte unsigned integer in big endian byte order.
+func (d *Decoder) Uint64BE() uint64 {
+	bo := d.bo
+	d.bo = binary.BigEndian
+	num := d.uint("uint64be", 8)
+	d.bo = bo
+
+	return uint64(num)
+}
+
+// Uint reads a one, two, four or eight byte unsigned integer.
+func (d *Decoder) Uint(size int) uint64 {
+	return d.uint("uint", size)
+}
+
+func (d *Decoder) uint(op string, size int) uint64 {
+	// skip if errored
+	if d.err != nil {
+		return 0
+	}
+
+	// check length
+	if !d.need(size) {
+		d.fail(ErrBufferTooShort, op, size)
+		return 0
+	}
+
+	// read
+	var u uint64
+	switch size {
+	case 1:
+		u = uint64(d.buf[0])
+	case 2:
+		u = uint64(d.bo.Uint16(d.buf))
+	case 4:
+		u = uint64(d.bo.Uint32(d.buf))
+	case 8:
+		u = d.bo.Uint64(d.buf)
+	default:
+		d.fail(ErrInvalidSize, op, size)
+		return 0
+	}
+
+	// trace
+	if d.trc != nil {
+		d.trc("uint", d.Offset(), size, u)
+	}
+
+	// slice
+	d.buf = d.buf[size:]
+
+	return u
+}
+
+func (d *Decoder) uintBE(op string, size int) uint64 {
+	bo := d.bo
+	d.bo = binary.BigEndian
+	num := d.uint(op, size)
+	d.bo = bo
+
+	return num
+}
+
+// Enum reads a one, two, four or eight byte unsigned integer that must not
+// exceed the specified maximum value.
+func (d *Decoder) Enum(size int, max uint64) uint64 {
+	// read number
+	num := d.uint("enum", size)
+	if d.err != nil {
+		return 0
+	}
+
+	// check value
+	if num > max {
+		d.failAt(ErrInvalidEnum, "enum", d.Offset()-size, size)
+		return 0
+	}
+
+	return num
+}
+
+// Version reads a one, two, four or eight byte unsigned version number and
+// calls the matching handler to decode the version specific data. It sets
+// ErrUnknownVersion if no handler matches and any error returned by the
+// handler. The decoded version is returned.
+func (d *Decoder) Version(size int, handlers map[uint64]func(dec *Decoder) error) uint64 {
+	// read version
+	version := d.uint("version", size)
+	if d.err != nil {
+		return 0
+	}
+
+	// get handler
+	handler, ok := handlers[version]
+	if !ok {
+		d.failAt(ErrUnknownVersion, "version", d.Offset()-size, size)
+		return 0
+	}
+
+	// decode body
+	err := handler(d)
+	if err != nil && d.err == nil {
+		d.err = err
+	}
+
+	return version
+}
+
+// List reads a variable count prefixed list and calls the provided function
+// once for every item. It sets ErrListTooLong if the count exceeds the
+// specified maximum, ErrInvalidLength if the maximum is negative and any error
+// returned by the function. The decoded count is returned.
+func (d *Decoder) List(maxLen int, fn func(dec *Decoder, i int) error) int {
+	// read count
+	num := d.varuint("list")
+	if d.err != nil {
+		return 0
+	}
+
+	// check count
+	if !d.count("list", num, maxLen) {
+		return 0
+	}
+
+	// decode items
+	if !d.enter("list") {
+		return 0
+	}
+	for i := 0; i < int(num) && d.err == nil; i++ {
+		err := fn(d, i)
+		if err != nil && d.err == nil {
+			d.err = err
+		}
+	}
+	d.leave()
+
+	return int(num)
+}
+
+// While calls the provided function until the buffer is exhausted or an error
+// occurred. It sets any error returned by the function and ErrNoProgress if an
+// iteration did not consume any bytes.
+func (d *Decoder) While(fn func(dec *Decoder) error) {
+	for d.Remaining() {
+		// get length
+		length := d.Length()
+
+		// decode item
+		err := fn(d)
+		if err != nil && d.err == nil {
+			d.err = err
+		}
+		if d.err != nil {
+			return
+		}
+
+		// check progress
+		if d.Length() == length {
+			d.fail(ErrNoProgress, "while", 0)
+			return
+		}
+	}
+}
+
+// PeekUint8 reads a one byte unsigned integer without consuming it.
+func (d *Decoder) PeekUint8() uint8 {
+	return uint8(d.peek("peekuint8", 1))
+}
+
+// PeekUint16 reads a two byte unsigned integer without consuming it.
+func (d *Decoder) PeekUint16() uint16 {
+	return uint16(d.peek("peekuint16", 2))
+}
+
+// PeekUint reads a one, two, four or eight byte unsigned integer without
+// consuming it.
+func (d *Decoder) PeekUint(size int) uint64 {
+	return d.peek("peekuint", size)
+}
+
+func (d *Decoder) peek(op string, size int) uint64 {
+	// read and restore
+	d.need(size)
+	buf := d.buf
+	num := d.uint(op, size)
+	d.buf = buf
+
+	return num
 }
 
-// Uint16 reads a two byte unsigned integer.
-func (d *Decoder) Uint16() uint16 {
-	return uint16(d.Uint(2))
+// PeekBytes reads a raw byte slice without consuming it. The byte slice may
+// change if the source byte slice changes.
+func (d *Decoder) PeekBytes(length int) []byte {
+	// read and restore
+	d.need(length)
+	buf := d.buf
+	res := d.bytes("peekbytes", length, false)
+	d.buf = buf
+
+	return res
 }
 
-// Uint32 reads a four byte unsigned integer.
-func (d *Decoder) Uint32() uint32 {
-	return uint32(d.Uint(4))
+// Float32 reads a four byte float.
+func (d *Decoder) Float32() float32 {
+	return math.Float32frombits(uint32(d.uint("float32", 4)))
 }
 
-// Uint64 reads an eight byte unsigned integer.
-func (d *Decoder) Uint64() uint64 {
-	return d.Uint(8)
+// Float64 reads an eight byte float.
+func (d *Decoder) Float64() float64 {
+	return math.Float64frombits(d.uint("float64", 8))
 }
 
-// Uint reads a one, two, four or eight byte unsigned integer.
-func (d *Decoder) Uint(size int) uint64 {
-	// skip if errored
+// Float32Checked reads a four byte float and sets ErrNonFiniteFloat if the
+// number is NaN or infinite.
+func (d *Decoder) Float32Checked() float32 {
+	// read number
+	num := math.Float32frombits(uint32(d.uint("float32checked", 4)))
 	if d.err != nil {
 		return 0
 	}
 
-	// check length
-	if len(d.buf) < size {
-		d.err = ErrBufferTooShort
+	// check number
+	if math.IsNaN(float64(num)) || math.IsInf(float64(num), 0) {
+		d.failAt(ErrNonFiniteFloat, "float32checked", d.Offset()-4, 4)
 		return 0
 	}
 
-	// read
-	var u uint64
-	switch size {
-	case 1:
-		u = uint64(d.buf[0])
-	case 2:
-		u = uint64(d.bo.Uint16(d.buf))
-	case 4:
-		u = uint64(d.bo.Uint32(d.buf))
-	case 8:
-		u = d.bo.Uint64(d.buf)
-	default:
-		d.err = ErrInvalidSize
+	return num
+}
+
+// Float64Checked reads an eight byte float and sets ErrNonFiniteFloat if the
+// number is NaN or infinite.
+func (d *Decoder) Float64Checked() float64 {
+	// read number
+	num := math.Float64frombits(d.uint("float64checked", 8))
+	if d.err != nil {
 		return 0
 	}
 
-	// slice
-	d.buf = d.buf[size:]
+	// check number
+	if math.IsNaN(num) || math.IsInf(num, 0) {
+		d.failAt(ErrNonFiniteFloat, "float64checked", d.Offset()-8, 8)
+		return 0
+	}
 
-	return u
+	return num
 }
 
-// Float32 reads a four byte float.
-func (d *Decoder) Float32() float32 {
-	return math.Float32frombits(d.Uint32())
+// FixedQ16 reads a Q16.16 signed fixed-point number from a four byte integer.
+func (d *Decoder) FixedQ16() float64 {
+	return d.Fixed(16, 16)
 }
 
-// Float64 reads an eight byte float.
-func (d *Decoder) Float64() float64 {
-	return math.Float64frombits(d.Uint64())
+// Fixed reads a signed fixed-point number with the specified amount of integer
+// and fractional bits. The sum of the bits must be 8, 16, 32 or 64.
+func (d *Decoder) Fixed(intBits, fracBits int) float64 {
+	// skip if errored
+	if d.err != nil {
+		return 0
+	}
+
+	// check bits
+	total := intBits + fracBits
+	if intBits < 0 || fracBits < 0 || (total != 8 && total != 16 && total != 32 && total != 64) {
+		d.fail(ErrInvalidSize, "fixed", total/8)
+		return 0
+	}
+
+	// read number
+	raw := d.int("fixed", total/8)
+	if d.err != nil {
+		return 0
+	}
+
+	return math.Ldexp(float64(raw), -fracBits)
 }
 
-// VarUint reads a variable unsigned integer.
+// VarUint reads a variable unsigned integer. Values that overflow 64 bits
+// result in ErrVarintOverflow.
 func (d *Decoder) VarUint() uint64 {
+	return d.varuint("varuint")
+}
+
+func (d *Decoder) varuint(op string) uint64 {
 	// skip if errored
 	if d.err != nil {
 		return 0
@@ -251,19 +1222,36 @@ func (d *Decoder) VarUint() uint64 {
 
 	// read
 	num, n := binary.Uvarint(d.buf)
-	if n <= 0 {
-		d.err = ErrBufferTooShort
+	if n == 0 && len(d.vec) > 0 {
+		d.upto(binary.MaxVarintLen64)
+		num, n = binary.Uvarint(d.buf)
+	}
+	if n < 0 {
+		d.fail(ErrVarintOverflow, op, -n)
+		return 0
+	} else if n == 0 {
+		d.fail(ErrBufferTooShort, op, 0)
 		return 0
 	}
 
+	// trace
+	if d.trc != nil {
+		d.trc("varuint", d.Offset(), n, num)
+	}
+
 	// slice
 	d.buf = d.buf[n:]
 
 	return num
 }
 
-// VarInt reads a variable signed integer.
+// VarInt reads a variable signed integer. Values that overflow 64 bits result
+// in ErrVarintOverflow.
 func (d *Decoder) VarInt() int64 {
+	return d.varint("varint")
+}
+
+func (d *Decoder) varint(op string) int64 {
 	// skip if errored
 	if d.err != nil {
 		return 0
@@ -271,33 +1259,96 @@ func (d *Decoder) VarInt() int64 {
 
 	// read
 	num, n := binary.Varint(d.buf)
-	if n <= 0 {
-		d.err = ErrBufferTooShort
+	if n == 0 && len(d.vec) > 0 {
+		d.upto(binary.MaxVarintLen64)
+		num, n = binary.Varint(d.buf)
+	}
+	if n < 0 {
+		d.fail(ErrVarintOverflow, op, -n)
+		return 0
+	} else if n == 0 {
+		d.fail(ErrBufferTooShort, op, 0)
 		return 0
 	}
 
+	// trace
+	if d.trc != nil {
+		d.trc("varint", d.Offset(), n, num)
+	}
+
 	// slice
 	d.buf = d.buf[n:]
 
 	return num
 }
 
+// Decimal reads a scaled decimal as a variable signed integer for the units
+// followed by a one byte scale.
+func (d *Decoder) Decimal() (int64, uint8) {
+	return d.decimal("decimal")
+}
+
+func (d *Decoder) decimal(op string) (int64, uint8) {
+	units := d.varint(op)
+	scale := uint8(d.uint(op, 1))
+	if d.err != nil {
+		return 0, 0
+	}
+
+	return units, scale
+}
+
+// StrictDecimal reads a scaled decimal like Decimal but sets ErrInvalidScale if
+// the scale exceeds the specified maximum.
+func (d *Decoder) StrictDecimal(maxScale uint8) (int64, uint8) {
+	// read decimal
+	units, scale := d.decimal("strictdecimal")
+	if d.err != nil {
+		return 0, 0
+	}
+
+	// check scale
+	if scale > maxScale {
+		d.failAt(ErrInvalidScale, "strictdecimal", d.Offset()-1, 1)
+		return 0, 0
+	}
+
+	return units, scale
+}
+
 // TimeUnix reads a Unix timestamps in seconds.
 func (d *Decoder) TimeUnix() time.Time {
-	return time.Unix(d.Int64(), 0).UTC()
+	return time.Unix(d.int("timeunix", 8), 0).UTC()
 }
 
 // String reads a raw string. If the string is not cloned it may change if
-// the source byte slice changes.
+// the source byte slice changes. With the "fpack_safe" build tag, strings and
+// byte slices are always cloned.
 func (d *Decoder) String(length int, clone bool) string {
+	return d.string("string", length, clone)
+}
+
+func (d *Decoder) string(op string, length int, clone bool) string {
 	// skip if errored
 	if d.err != nil {
 		return ""
 	}
 
+	// check size
+	if length < 0 {
+		d.fail(ErrInvalidSize, op, length)
+		return ""
+	}
+
 	// check length
-	if len(d.buf) < length {
-		d.err = ErrBufferTooShort
+	if !d.need(length) {
+		d.fail(ErrBufferTooShort, op, length)
+		return ""
+	}
+
+	// validate string
+	if d.utf && !utf8.Valid(d.buf[:length]) {
+		d.fail(ErrInvalidUTF8, op, length)
 		return ""
 	}
 
@@ -305,12 +1356,17 @@ func (d *Decoder) String(length int, clone bool) string {
 	var str string
 	if clone {
 		if d.arn != nil {
-			str = cast.ToString(d.arn.Clone(d.buf[:length]))
+			str = toString(d.arn.Clone(d.buf[:length]))
 		} else {
 			str = string(d.buf[:length])
 		}
 	} else {
-		str = cast.ToString(d.buf[:length])
+		str = toString(d.buf[:length])
+	}
+
+	// trace
+	if d.trc != nil {
+		d.trc("string", d.Offset(), length, str)
 	}
 
 	// resize
@@ -320,22 +1376,33 @@ func (d *Decoder) String(length int, clone bool) string {
 }
 
 // Bytes reads a raw byte slice. If the byte slice is not cloned it may
-// change if the source byte slice changes.
+// change if the source byte slice changes. With the "fpack_safe" build tag,
+// strings and byte slices are always cloned.
 func (d *Decoder) Bytes(length int, clone bool) []byte {
+	return d.bytes("bytes", length, clone)
+}
+
+func (d *Decoder) bytes(op string, length int, clone bool) []byte {
 	// skip if errored
 	if d.err != nil {
 		return nil
 	}
 
+	// check size
+	if length < 0 {
+		d.fail(ErrInvalidSize, op, length)
+		return nil
+	}
+
 	// check length
-	if len(d.buf) < length {
-		d.err = ErrBufferTooShort
+	if !d.need(length) {
+		d.fail(ErrBufferTooShort, op, length)
 		return nil
 	}
 
 	// clone or set bytes
 	var buf []byte
-	if clone {
+	if clone || safeMode {
 		if d.arn != nil {
 			buf = d.arn.Clone(d.buf[:length])
 		} else {
@@ -346,34 +1413,235 @@ func (d *Decoder) Bytes(length int, clone bool) []byte {
 		buf = d.buf[:length]
 	}
 
+	// trace
+	if d.trc != nil {
+		d.trc("bytes", d.Offset(), length, buf)
+	}
+
 	// resize
 	d.buf = d.buf[length:]
 
 	return buf
 }
 
+// CopyTo writes the next n bytes to the provided writer using a single write.
+// Writer errors and short writes (io.ErrShortWrite) are set on the decoder.
+func (d *Decoder) CopyTo(w io.Writer, n int) {
+	// skip if errored
+	if d.err != nil {
+		return
+	}
+
+	// check size
+	if n < 0 {
+		d.fail(ErrInvalidSize, "copyto", n)
+		return
+	}
+
+	// check length
+	if !d.need(n) {
+		d.fail(ErrBufferTooShort, "copyto", n)
+		return
+	}
+
+	// write bytes
+	m, err := w.Write(d.buf[:n])
+	if err == nil && m != n {
+		err = io.ErrShortWrite
+	}
+	if err != nil {
+		d.fail(err, "copyto", n)
+		return
+	}
+
+	// trace
+	if d.trc != nil {
+		d.trc("copyto", d.Offset(), n, nil)
+	}
+
+	// slice
+	d.buf = d.buf[n:]
+}
+
+// Read implements the io.Reader interface by copying the remaining bytes to
+// the provided slice. It returns io.EOF once all bytes have been read and the
+// decoder error if one has been set.
+func (d *Decoder) Read(p []byte) (int, error) {
+	// check error
+	if d.err != nil {
+		return 0, d.err
+	}
+
+	// check length
+	if len(p) == 0 {
+		return 0, nil
+	} else if !d.need(1) {
+		return 0, io.EOF
+	}
+
+	// copy bytes
+	n := copy(p, d.buf)
+
+	// trace
+	if d.trc != nil {
+		d.trc("read", d.Offset(), n, nil)
+	}
+
+	// slice
+	d.buf = d.buf[n:]
+
+	return n, nil
+}
+
+// ReadByte implements the io.ByteReader interface by reading a single byte. It
+// returns io.EOF once all bytes have been read and the decoder error if one has
+// been set. Like Read, it does not set an error on the decoder.
+func (d *Decoder) ReadByte() (byte, error) {
+	// check error
+	if d.err != nil {
+		return 0, d.err
+	}
+
+	// check length
+	if !d.need(1) {
+		return 0, io.EOF
+	}
+
+	// get byte
+	b := d.buf[0]
+
+	// trace
+	if d.trc != nil {
+		d.trc("readbyte", d.Offset(), 1, b)
+	}
+
+	// slice
+	d.unr = d.buf
+	d.buf = d.buf[1:]
+	d.uof = d.Offset()
+
+	return b, nil
+}
+
+// UnreadByte implements the io.ByteScanner interface by stepping back the byte
+// read by the last call to ReadByte. It returns ErrInvalidUnread if no byte has
+// been read since the last reset, bytes have been consumed or the decoder has
+// been rewound since, or the byte has already been unread.
+func (d *Decoder) UnreadByte() error {
+	// check error
+	if d.err != nil {
+		return d.err
+	}
+
+	// check position
+	if d.unr == nil || d.uof != d.Offset() || len(d.unr) != len(d.buf)+1 {
+		d.unr = nil
+		return ErrInvalidUnread
+	}
+
+	// restore
+	d.buf = d.unr
+	d.unr = nil
+
+	return nil
+}
+
 // FixString reads a fixed length prefixed string. If the string is not cloned it
-// may change if the source byte slice changes.
+// may change if the source byte slice changes. Invalid prefix sizes result in
+// a SizeError.
 func (d *Decoder) FixString(lenSize int, clone bool) string {
-	return d.String(int(d.Uint(lenSize)), clone)
+	if !d.prefix("fixstring", lenSize) {
+		return ""
+	}
+	return d.string("fixstring", d.length("fixstring", d.uint("fixstring", lenSize)), clone)
 }
 
 // FixBytes reads a fixed length prefixed byte slice. If the byte slice is not
-// cloned it may change if the source byte slice changes.
+// cloned it may change if the source byte slice changes. Invalid prefix sizes
+// result in a SizeError.
 func (d *Decoder) FixBytes(lenSize int, clone bool) []byte {
-	return d.Bytes(int(d.Uint(lenSize)), clone)
+	if !d.prefix("fixbytes", lenSize) {
+		return nil
+	}
+	return d.bytes("fixbytes", d.length("fixbytes", d.uint("fixbytes", lenSize)), clone)
+}
+
+func (d *Decoder) prefix(op string, lenSize int) bool {
+	// skip if errored
+	if d.err != nil {
+		return false
+	}
+
+	// check size
+	if !validPrefix(lenSize) {
+		d.fail(&SizeError{Op: op, Size: lenSize}, op, lenSize)
+		return false
+	}
+
+	return true
+}
+
+// HexBytes reads a fixed length prefixed hex string and returns the decoded
+// bytes. The returned byte slice is always allocated, using the arena if
+// configured. Odd lengths and invalid characters result in ErrInvalidHex and
+// invalid prefix sizes in a SizeError.
+func (d *Decoder) HexBytes(lenSize int) []byte {
+	// check prefix
+	if !d.prefix("hexbytes", lenSize) {
+		return nil
+	}
+
+	// read length
+	length := d.length("hexbytes", d.uint("hexbytes", lenSize))
+	if d.err != nil {
+		return nil
+	}
+
+	// check length
+	if !d.need(length) {
+		d.fail(ErrBufferTooShort, "hexbytes", length)
+		return nil
+	} else if length%2 != 0 {
+		d.fail(ErrInvalidHex, "hexbytes", length)
+		return nil
+	}
+
+	// allocate buffer
+	var buf []byte
+	if d.arn != nil {
+		buf = d.arn.Get(hex.DecodedLen(length), false)
+	} else {
+		buf = make([]byte, hex.DecodedLen(length))
+	}
+
+	// decode hex
+	_, err := hex.Decode(buf, d.buf[:length])
+	if err != nil {
+		d.fail(ErrInvalidHex, "hexbytes", length)
+		return nil
+	}
+
+	// trace
+	if d.trc != nil {
+		d.trc("hexbytes", d.Offset(), length, buf)
+	}
+
+	// slice
+	d.buf = d.buf[length:]
+
+	return buf
 }
 
 // VarString reads a variable length prefixed string. If the string is not
 // cloned it may change if the source byte slice changes.
 func (d *Decoder) VarString(clone bool) string {
-	return d.String(int(d.VarUint()), clone)
+	return d.string("varstring", d.length("varstring", d.varuint("varstring")), clone)
 }
 
 // VarBytes reads a variable length prefixed byte slice. If the byte slice is
 // not cloned it may change if the source byte slice changes.
 func (d *Decoder) VarBytes(clone bool) []byte {
-	return d.Bytes(int(d.VarUint()), clone)
+	return d.bytes("varbytes", d.length("varbytes", d.varuint("varbytes")), clone)
 }
 
 // DelString reads a suffix delimited string. If the string is not cloned it
@@ -386,14 +1654,17 @@ func (d *Decoder) DelString(delim string, clone bool) string {
 
 	// check delimiter
 	if len(delim) == 0 {
-		d.err = ErrEmptyDelimiter
+		d.fail(ErrEmptyDelimiter, "delstring", 0)
 		return ""
 	}
 
+	// stitch following slices
+	d.need(d.Length())
+
 	// find index
-	idx := bytes.Index(d.buf, cast.ToBytes(delim))
+	idx := bytes.Index(d.buf, toBytes(delim))
 	if idx < 0 {
-		d.err = ErrBufferTooShort
+		d.fail(ErrBufferTooShort, "delstring", 0)
 		return ""
 	}
 
@@ -414,14 +1685,17 @@ func (d *Decoder) DelBytes(delim []byte, clone bool) []byte {
 
 	// check delimiter
 	if len(delim) == 0 {
-		d.err = ErrEmptyDelimiter
+		d.fail(ErrEmptyDelimiter, "delbytes", 0)
 		return nil
 	}
 
+	// stitch following slices
+	d.need(d.Length())
+
 	// find index
 	idx := bytes.Index(d.buf, delim)
 	if idx < 0 {
-		d.err = ErrBufferTooShort
+		d.fail(ErrBufferTooShort, "delbytes", 0)
 		return nil
 	}
 
@@ -432,8 +1706,134 @@ func (d *Decoder) DelBytes(delim []byte, clone bool) []byte {
 	return buf
 }
 
+// DelStringAny reads a suffix delimited string that is terminated by any of the
+// provided delimiters. It returns the string and the index of the matched
+// delimiter. If multiple delimiters match at the same position the first one
+// is used. If the string is not cloned it may change if the source byte slice
+// changes.
+func (d *Decoder) DelStringAny(delims []string, clone bool) (string, int) {
+	// skip if errored
+	if d.err != nil {
+		return "", -1
+	}
+
+	// find index
+	idx, which := d.indexAny(len(delims), func(i int) int {
+		return len(delims[i])
+	}, func(i, j int) byte {
+		return delims[i][j]
+	})
+	if idx < 0 {
+		return "", -1
+	}
+
+	// decode
+	str := d.String(idx, clone)
+	d.Skip(len(delims[which]))
+
+	return str, which
+}
+
+// DelBytesAny reads a suffix delimited byte slice that is terminated by any of
+// the provided delimiters. It returns the byte slice and the index of the
+// matched delimiter. If multiple delimiters match at the same position the
+// first one is used. If the byte slice is not cloned it may change if the
+// source byte slice changes.
+func (d *Decoder) DelBytesAny(delims [][]byte, clone bool) ([]byte, int) {
+	// skip if errored
+	if d.err != nil {
+		return nil, -1
+	}
+
+	// find index
+	idx, which := d.indexAny(len(delims), func(i int) int {
+		return len(delims[i])
+	}, func(i, j int) byte {
+		return delims[i][j]
+	})
+	if idx < 0 {
+		return nil, -1
+	}
+
+	// decode
+	buf := d.Bytes(idx, clone)
+	d.Skip(len(delims[which]))
+
+	return buf, which
+}
+
+func (d *Decoder) indexAny(num int, length func(i int) int, at func(i, j int) byte) (int, int) {
+	// check delimiters
+	if num == 0 {
+		d.fail(ErrEmptyDelimiter, "delany", 0)
+		return -1, -1
+	}
+	single := true
+	for i := 0; i < num; i++ {
+		switch length(i) {
+		case 0:
+			d.fail(ErrEmptyDelimiter, "delany", 0)
+			return -1, -1
+		case 1:
+		default:
+			single = false
+		}
+	}
+
+	// stitch following slices
+	d.need(d.Length())
+
+	// handle single byte delimiters
+	if single {
+		// prepare table
+		var table [256]int
+		for i := num - 1; i >= 0; i-- {
+			table[at(i, 0)] = i + 1
+		}
+
+		// find index
+		for idx, b := range d.buf {
+			if table[b] > 0 {
+				return idx, table[b] - 1
+			}
+		}
+
+		d.fail(ErrBufferTooShort, "delany", 0)
+		return -1, -1
+	}
+
+	// find index
+	for idx := range d.buf {
+		for i := 0; i < num; i++ {
+			n := length(i)
+			if len(d.buf)-idx < n {
+				continue
+			}
+			match := true
+			for j := 0; j < n; j++ {
+				if d.buf[idx+j] != at(i, j) {
+					match = false
+					break
+				}
+			}
+			if match {
+				return idx, i
+			}
+		}
+	}
+
+	d.fail(ErrBufferTooShort, "delany", 0)
+	return -1, -1
+}
+
 // Tail reads a tail byte slice. If the byte slice is not cloned it may change
 // if the source byte slice changes.
 func (d *Decoder) Tail(clone bool) []byte {
-	return d.Bytes(len(d.buf), clone)
+	return d.Bytes(d.Length(), clone)
+}
+
+// TailString reads a tail string. If the string is not cloned it may change if
+// the source byte slice changes.
+func (d *Decoder) TailString(clone bool) string {
+	return d.String(d.Length(), clone)
 }
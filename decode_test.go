@@ -1,8 +1,13 @@
 package fpack
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
 	"io"
 	"math"
+	"strings"
 	"testing"
 	"time"
 
@@ -109,6 +114,56 @@ func testDecode(t *testing.T, clone bool) {
 	assert.Equal(t, []byte("baz"), tail)
 }
 
+func TestMustDecode(t *testing.T) {
+	var num uint16
+	err := MustDecode([]byte{1, 2}, func(dec *Decoder) {
+		num = dec.Uint16()
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(0x0102), num)
+
+	err = MustDecode([]byte{1}, func(dec *Decoder) {
+		dec.Uint16()
+	})
+	assert.ErrorIs(t, err, ErrBufferTooShort)
+
+	err = MustDecode([]byte{1, 2, 3}, func(dec *Decoder) {
+		dec.Uint16()
+	})
+	assert.ErrorIs(t, err, ErrRemainingBytes)
+}
+
+func TestDecodePartial(t *testing.T) {
+	buf := []byte{0, 1, 0, 2, 3}
+
+	var nums []uint16
+	for len(buf) > 0 {
+		var num uint16
+		n, err := DecodePartial(buf, func(dec *Decoder) error {
+			num = dec.Uint16()
+			return nil
+		})
+		if err != nil {
+			assert.ErrorIs(t, err, ErrBufferTooShort)
+			assert.Equal(t, 0, n)
+			break
+		}
+		assert.Equal(t, 2, n)
+		nums = append(nums, num)
+		buf = buf[n:]
+	}
+	assert.Equal(t, []uint16{1, 2}, nums)
+	assert.Equal(t, []byte{3}, buf)
+
+	n, err := DecodePartial([]byte{1, 2, 3}, func(dec *Decoder) error {
+		dec.Uint8()
+		dec.Uint8()
+		return io.EOF
+	})
+	assert.Equal(t, io.EOF, err)
+	assert.Equal(t, 2, n)
+}
+
 func TestDecodeRemaining(t *testing.T) {
 	err := Decode([]byte{42, 84}, func(dec *Decoder) error {
 		assert.True(t, dec.Remaining())
@@ -123,6 +178,128 @@ func TestDecodeRemaining(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestDecodeAllowRemaining(t *testing.T) {
+	var num uint8
+	err := Decode([]byte{1, 2, 3}, func(dec *Decoder) error {
+		num = dec.Uint8()
+		if num > 0 {
+			dec.AllowRemaining()
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(1), num)
+
+	err = Decode([]byte{0, 2, 3}, func(dec *Decoder) error {
+		num = dec.Uint8()
+		if num > 0 {
+			dec.AllowRemaining()
+		}
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrRemainingBytes)
+
+	dec := NewDecoder(nil)
+	dec.AllowRemaining()
+	dec.Reset(nil)
+	assert.False(t, dec.rem)
+}
+
+func TestDecodeOffset(t *testing.T) {
+	var offsets []int
+	err := Decode(dummy, func(dec *Decoder) error {
+		offsets = append(offsets, dec.Offset())
+		dec.Skip(3)
+		offsets = append(offsets, dec.Offset())
+		dec.Skip(len(dummy) - 14)
+		offsets = append(offsets, dec.Offset())
+		dec.DelString("\x00", false)
+		offsets = append(offsets, dec.Offset())
+		dec.DelBytes([]byte{0}, false)
+		offsets = append(offsets, dec.Offset())
+		dec.Tail(false)
+		offsets = append(offsets, dec.Offset())
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{0, 3, len(dummy) - 11, len(dummy) - 7, len(dummy) - 3, len(dummy)}, offsets)
+
+	dec := NewDecoder([]byte{0x80, 0x04, 1})
+	dec.VarUint()
+	assert.Equal(t, 2, dec.Offset())
+	dec.Reset([]byte{1})
+	assert.Equal(t, 0, dec.Offset())
+}
+
+func TestDecodeSkipPadding(t *testing.T) {
+	var str string
+	err := Decode([]byte("foo  "), func(dec *Decoder) error {
+		str = dec.String(3, false)
+		dec.SkipPadding(2, ' ')
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "foo", str)
+
+	err = Decode([]byte("foo x"), func(dec *Decoder) error {
+		dec.String(3, false)
+		dec.SkipPadding(2, ' ')
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrInvalidPadding)
+
+	err = Decode([]byte("foo "), func(dec *Decoder) error {
+		dec.String(3, false)
+		dec.SkipPadding(2, ' ')
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrBufferTooShort)
+}
+
+func TestDecodeRest(t *testing.T) {
+	dec := NewDecoder([]byte("foobar"))
+	dec.Skip(3)
+	assert.Equal(t, []byte("bar"), dec.Rest())
+	assert.Equal(t, 3, dec.RestLen())
+	assert.Equal(t, 3, dec.Offset())
+
+	dec.Skip(4)
+	assert.Nil(t, dec.Rest())
+	assert.Equal(t, 3, dec.RestLen())
+}
+
+func TestDecodeDiscard(t *testing.T) {
+	var num uint8
+	err := Decode([]byte("\x01foobar"), func(dec *Decoder) error {
+		num = dec.Uint8()
+		dec.Discard(3)
+		dec.DiscardRest()
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(1), num)
+
+	err = Decode([]byte("foo"), func(dec *Decoder) error {
+		dec.Discard(4)
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrBufferTooShort)
+
+	err = Decode([]byte("foo"), func(dec *Decoder) error {
+		dec.Discard(-1)
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrInvalidSize)
+
+	out, err := DumpDecode([]byte("\x01foobar"), func(dec *Decoder) error {
+		dec.Uint8()
+		dec.DiscardRest()
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Contains(t, out, "discard")
+}
+
 func TestDecodeErrors(t *testing.T) {
 	table := []func(*Decoder){
 		func(dec *Decoder) {
@@ -214,7 +391,7 @@ func TestDecodeShortBuffer(t *testing.T) {
 			item(dec)
 			return nil
 		})
-		assert.Equal(t, ErrBufferTooShort, err, i)
+		assert.ErrorIs(t, err, ErrBufferTooShort, i)
 	}
 
 	table = []func(*Decoder){
@@ -243,7 +420,7 @@ func TestDecodeShortBuffer(t *testing.T) {
 			item(dec)
 			return nil
 		})
-		assert.Equal(t, ErrBufferTooShort, err, i)
+		assert.ErrorIs(t, err, ErrBufferTooShort, i)
 	}
 }
 
@@ -253,14 +430,14 @@ func TestDecodeEmptyDelimiters(t *testing.T) {
 		return nil
 	})
 	assert.Error(t, err)
-	assert.Equal(t, ErrEmptyDelimiter, err)
+	assert.ErrorIs(t, err, ErrEmptyDelimiter)
 
 	err = Decode(make([]byte, 8), func(dec *Decoder) error {
 		dec.DelBytes(nil, false)
 		return nil
 	})
 	assert.Error(t, err)
-	assert.Equal(t, ErrEmptyDelimiter, err)
+	assert.ErrorIs(t, err, ErrEmptyDelimiter)
 }
 
 func TestDecodeInvalidSize(t *testing.T) {
@@ -269,14 +446,145 @@ func TestDecodeInvalidSize(t *testing.T) {
 		return nil
 	})
 	assert.Error(t, err)
-	assert.Equal(t, ErrInvalidSize, err)
+	assert.ErrorIs(t, err, ErrInvalidSize)
 
 	err = Decode(make([]byte, 8), func(dec *Decoder) error {
 		dec.Uint(3)
 		return nil
 	})
 	assert.Error(t, err)
-	assert.Equal(t, ErrInvalidSize, err)
+	assert.ErrorIs(t, err, ErrInvalidSize)
+
+	table := []func(*Decoder){
+		func(dec *Decoder) {
+			dec.Skip(-5)
+		},
+		func(dec *Decoder) {
+			dec.String(-1, false)
+		},
+		func(dec *Decoder) {
+			dec.Bytes(-1, false)
+		},
+		func(dec *Decoder) {
+			dec.PeekBytes(-1)
+		},
+		func(dec *Decoder) {
+			dec.CopyTo(io.Discard, -1)
+		},
+		func(dec *Decoder) {
+			dec.Limit(-1, func(dec *Decoder) error {
+				return nil
+			})
+		},
+		func(dec *Decoder) {
+			dec.VarString(false)
+		},
+		func(dec *Decoder) {
+			dec.FixBytes(8, false)
+		},
+	}
+
+	for _, item := range table {
+		err = Decode([]byte("\xff\xff\xff\xff\xff\xff\xff\xff\xff\x01"), func(dec *Decoder) error {
+			item(dec)
+			return nil
+		})
+		assert.ErrorIs(t, err, ErrInvalidSize)
+	}
+}
+
+func TestDecodeInvalidPrefix(t *testing.T) {
+	table := []func(*Decoder){
+		func(dec *Decoder) {
+			dec.FixString(3, false)
+		},
+		func(dec *Decoder) {
+			dec.FixBytes(3, false)
+		},
+		func(dec *Decoder) {
+			dec.HexBytes(3)
+		},
+		func(dec *Decoder) {
+			dec.UTF16String(3)
+		},
+	}
+
+	for _, item := range table {
+		err := Decode(make([]byte, 8), func(dec *Decoder) error {
+			item(dec)
+			return nil
+		})
+		assert.True(t, errors.Is(err, ErrInvalidSize))
+		var se *SizeError
+		assert.True(t, errors.As(err, &se))
+		assert.Equal(t, 3, se.Size)
+	}
+
+	err := Decode(make([]byte, 8), func(dec *Decoder) error {
+		dec.FixString(3, false)
+		return nil
+	})
+	assert.Equal(t, "fixstring at offset 0 (length 3): invalid size: fixstring length prefix size 3", err.Error())
+}
+
+func TestDecodeLengthOverflow(t *testing.T) {
+	// simulate 32-bit platform
+	maxInt = math.MaxInt32
+	defer func() {
+		maxInt = math.MaxInt
+	}()
+
+	err := Decode([]byte{0, 0, 1, 0, 0, 0, 0, 0}, func(dec *Decoder) error {
+		dec.FixBytes(8, false)
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrInvalidSize)
+
+	err = Decode([]byte{0, 0, 0, 0, 0x80, 0, 0, 0}, func(dec *Decoder) error {
+		dec.FixBytes(8, false)
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrInvalidSize)
+
+	err = Decode([]byte{0, 0, 0, 0, 0x7f, 0xff, 0xff, 0xff}, func(dec *Decoder) error {
+		dec.FixBytes(8, false)
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrBufferTooShort)
+
+	err = Decode([]byte{0x80, 0x80, 0x80, 0x80, 0x80, 0x20}, func(dec *Decoder) error {
+		dec.VarString(false)
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrInvalidSize)
+}
+
+func TestDecodeVarintOverflow(t *testing.T) {
+	overflow := []byte("\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\x01")
+
+	err := Decode(overflow, func(dec *Decoder) error {
+		dec.VarUint()
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrVarintOverflow)
+
+	err = Decode(overflow, func(dec *Decoder) error {
+		dec.VarInt()
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrVarintOverflow)
+
+	err = Decode(overflow[:9], func(dec *Decoder) error {
+		dec.VarUint()
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrBufferTooShort)
+
+	err = Decode(overflow[:9], func(dec *Decoder) error {
+		dec.VarInt()
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrBufferTooShort)
 }
 
 func TestDecodeRemainingBytes(t *testing.T) {
@@ -284,10 +592,34 @@ func TestDecodeRemainingBytes(t *testing.T) {
 		dec.Uint8()
 		return nil
 	})
-	assert.Equal(t, ErrRemainingBytes, err)
+	assert.ErrorIs(t, err, ErrRemainingBytes)
+	assert.Equal(t, &RemainingError{Offset: 1, Length: 1, Preview: []byte{84}}, err)
+	assert.Equal(t, "remaining bytes: 1 at offset 1 (54)", err.Error())
+
+	data := make([]byte, 32)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	err = Decode(data, func(dec *Decoder) error {
+		dec.Skip(8)
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrRemainingBytes)
+	assert.Equal(t, "remaining bytes: 24 at offset 8 (08090a0b0c0d0e0f1011121314151617...)", err.Error())
+
+	assert.Equal(t, 0.0, testing.AllocsPerRun(10, func() {
+		_ = Decode(data, func(dec *Decoder) error {
+			dec.Skip(32)
+			return nil
+		})
+	}))
 }
 
 func TestDecodeAllocation(t *testing.T) {
+	if safeMode {
+		t.Skip("values are cloned in safe mode")
+	}
+
 	assert.Equal(t, 0.0, testing.AllocsPerRun(10, func() {
 		err := Decode(dummy, func(dec *Decoder) error {
 			dec.Skip(3)
@@ -341,6 +673,68 @@ func TestDecodeByteOrder(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestDecodeSetByteOrder(t *testing.T) {
+	err := Decode([]byte("*\x00\x00*"), func(dec *Decoder) error {
+		dec.SetByteOrder(binary.LittleEndian)
+		assert.Equal(t, uint16(42), dec.Uint16())
+		dec.SetByteOrder(binary.BigEndian)
+		assert.Equal(t, uint16(42), dec.Uint16())
+		return nil
+	})
+	assert.NoError(t, err)
+}
+
+func TestDecodeExplicitByteOrder(t *testing.T) {
+	data := []byte("\x01\x00\x00\x01" +
+		"\x01\x00\x00\x00\x00\x00\x00\x01" +
+		"\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x01" +
+		"\xFE\xFF\xFF\xFE" +
+		"\xFE\xFF\xFF\xFF\xFF\xFF\xFF\xFE" +
+		"\xFE\xFF\xFF\xFF\xFF\xFF\xFF\xFF\xFF\xFF\xFF\xFF\xFF\xFF\xFF\xFE" +
+		"\x01\x00")
+
+	err := Decode(data, func(dec *Decoder) error {
+		assert.Equal(t, uint16(1), dec.Uint16LE())
+		assert.Equal(t, uint16(1), dec.Uint16BE())
+		assert.Equal(t, uint32(1), dec.Uint32LE())
+		assert.Equal(t, uint32(1), dec.Uint32BE())
+		assert.Equal(t, uint64(1), dec.Uint64LE())
+		assert.Equal(t, uint64(1), dec.Uint64BE())
+		dec.UseLittleEndian()
+		assert.Equal(t, int16(-2), dec.Int16LE())
+		assert.Equal(t, int16(-2), dec.Int16BE())
+		assert.Equal(t, int32(-2), dec.Int32LE())
+		assert.Equal(t, int32(-2), dec.Int32BE())
+		assert.Equal(t, int64(-2), dec.Int64LE())
+		assert.Equal(t, int64(-2), dec.Int64BE())
+		assert.Equal(t, uint16(1), dec.Uint16())
+		return nil
+	})
+	assert.NoError(t, err)
+}
+
+func TestDecodeSafeMode(t *testing.T) {
+	buf := []byte("foobar")
+
+	var str string
+	var bytes []byte
+	err := Decode(buf, func(dec *Decoder) error {
+		str = dec.String(3, false)
+		bytes = dec.Bytes(3, false)
+		return nil
+	})
+	assert.NoError(t, err)
+
+	copy(buf, "bazqux")
+	if safeMode {
+		assert.Equal(t, "foo", str)
+		assert.Equal(t, []byte("bar"), bytes)
+	} else {
+		assert.Equal(t, "baz", str)
+		assert.Equal(t, []byte("qux"), bytes)
+	}
+}
+
 func TestDecodeArena(t *testing.T) {
 	arena := NewArena(Global(), 105*10)
 	defer arena.Release()
@@ -356,7 +750,7 @@ func TestDecodeArena(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, 10, arena.Length())
 
-	assert.Equal(t, 0.0, testing.AllocsPerRun(100, func() {
+	allocs := testing.AllocsPerRun(100, func() {
 		err := Decode(sample, func(dec *Decoder) error {
 			dec.UseArena(arena)
 			dec.String(5, true)
@@ -364,45 +758,1024 @@ func TestDecodeArena(t *testing.T) {
 			return nil
 		})
 		assert.NoError(t, err)
-	}))
+	})
+	if !safeMode {
+		assert.Equal(t, 0.0, allocs)
+	}
 	assert.Equal(t, 1020, arena.Length())
 }
 
-func BenchmarkDecode(b *testing.B) {
-	b.ReportAllocs()
-	b.ResetTimer()
+func TestDecodeStrictBool(t *testing.T) {
+	var b1, b2 bool
+	err := Decode([]byte{1, 0}, func(dec *Decoder) error {
+		b1 = dec.StrictBool()
+		b2 = dec.StrictBool()
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.True(t, b1)
+	assert.False(t, b2)
 
-	for i := 0; i < b.N; i++ {
-		err := Decode(dummy, func(dec *Decoder) error {
-			dec.Skip(3)
-			dec.Bool()
-			dec.Bool()
-			dec.Int8()
-			dec.Int8()
-			dec.Int16()
-			dec.Int16()
-			dec.Int32()
-			dec.Int32()
-			dec.Int64()
-			dec.Int64()
-			dec.Int(4)
+	err = Decode([]byte{2}, func(dec *Decoder) error {
+		dec.StrictBool()
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrInvalidBool)
+}
+
+func TestDecodeOption(t *testing.T) {
+	var num uint8
+	var present1, present2 bool
+	err := Decode([]byte{1, 42, 0}, func(dec *Decoder) error {
+		present1 = dec.Option(func(dec *Decoder) {
+			num = dec.Uint8()
+		})
+		present2 = dec.Option(func(dec *Decoder) {
+			panic("unexpected")
+		})
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.True(t, present1)
+	assert.False(t, present2)
+	assert.Equal(t, uint8(42), num)
+
+	err = Decode([]byte{2, 42}, func(dec *Decoder) error {
+		dec.Option(func(dec *Decoder) {
 			dec.Uint8()
-			dec.Uint16()
-			dec.Uint32()
-			dec.Uint64()
-			dec.Float32()
-			dec.Float64()
-			dec.VarInt()
-			dec.VarUint()
-			dec.String(3, false)
-			dec.Bytes(3, false)
-			dec.FixString(1, false)
-			dec.FixBytes(1, false)
-			dec.VarString(false)
-			dec.VarBytes(false)
-			dec.DelString("\x00", false)
-			dec.DelBytes([]byte{0}, false)
-			dec.Tail(false)
+		})
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrInvalidOption)
+}
+
+func TestDecodeEnum(t *testing.T) {
+	var num1, num2 uint64
+	err := Decode([]byte{3, 0, 0}, func(dec *Decoder) error {
+		num1 = dec.Enum(1, 3)
+		num2 = dec.Enum(2, 3)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), num1)
+	assert.Equal(t, uint64(0), num2)
+
+	err = Decode([]byte{4}, func(dec *Decoder) error {
+		dec.Enum(1, 3)
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrInvalidEnum)
+}
+
+func TestDecodeDecimal(t *testing.T) {
+	data := []byte{0xF1, 0xC0, 0x01, 0x02, 0x0E, 0x00}
+
+	var units1, units2 int64
+	var scale1, scale2 uint8
+	err := Decode(data, func(dec *Decoder) error {
+		units1, scale1 = dec.Decimal()
+		units2, scale2 = dec.StrictDecimal(2)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(-12345), units1)
+	assert.Equal(t, uint8(2), scale1)
+	assert.Equal(t, int64(7), units2)
+	assert.Equal(t, uint8(0), scale2)
+
+	err = Decode(data, func(dec *Decoder) error {
+		dec.StrictDecimal(1)
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrInvalidScale)
+}
+
+func TestDecodeVersion(t *testing.T) {
+	var num uint64
+	handlers := map[uint64]func(*Decoder) error{
+		1: func(dec *Decoder) error {
+			num = uint64(dec.Uint8())
+			return nil
+		},
+		2: func(dec *Decoder) error {
+			num = uint64(dec.Uint16())
+			return nil
+		},
+		3: func(dec *Decoder) error {
+			return io.EOF
+		},
+	}
+
+	var version uint64
+	err := Decode([]byte{2, 0, 42}, func(dec *Decoder) error {
+		version = dec.Version(1, handlers)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), version)
+	assert.Equal(t, uint64(42), num)
+
+	err = Decode([]byte{3}, func(dec *Decoder) error {
+		dec.Version(1, handlers)
+		return nil
+	})
+	assert.Equal(t, io.EOF, err)
+
+	err = Decode([]byte{4}, func(dec *Decoder) error {
+		dec.Version(1, handlers)
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrUnknownVersion)
+}
+
+func TestDecodeHexBytes(t *testing.T) {
+	var buf1, buf2 []byte
+	err := Decode([]byte("\x08DEADbeef\x00\x00"), func(dec *Decoder) error {
+		buf1 = dec.HexBytes(1)
+		buf2 = dec.HexBytes(2)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0xDE, 0xAD, 0xBE, 0xEF}, buf1)
+	assert.Equal(t, []byte{}, buf2)
+
+	table := map[string]error{
+		"\x03abc":    ErrInvalidHex,
+		"\x02xy":     ErrInvalidHex,
+		"\x04ab":     ErrBufferTooShort,
+		"\x02ab\x00": ErrRemainingBytes,
+	}
+	for data, expected := range table {
+		err = Decode([]byte(data), func(dec *Decoder) error {
+			dec.HexBytes(1)
+			return nil
+		})
+		assert.ErrorIs(t, err, expected, data)
+	}
+}
+
+func TestDecodeTailString(t *testing.T) {
+	var str1, str2 string
+	err := Decode([]byte("*foo"), func(dec *Decoder) error {
+		dec.Uint8()
+		str1 = dec.TailString(false)
+		str2 = dec.TailString(true)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "foo", str1)
+	assert.Equal(t, "", str2)
+}
+
+func TestDecodeDelAny(t *testing.T) {
+	var strs []string
+	var which []int
+	err := Decode([]byte("foo\nbar;baz\r\nqux\n"), func(dec *Decoder) error {
+		for dec.Remaining() {
+			str, idx := dec.DelStringAny([]string{"\n", ";"}, false)
+			strs = append(strs, str)
+			which = append(which, idx)
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"foo", "bar", "baz\r", "qux"}, strs)
+	assert.Equal(t, []int{0, 1, 0, 0}, which)
+
+	var bufs [][]byte
+	which = nil
+	err = Decode([]byte("foo\r\nbar;baz\n"), func(dec *Decoder) error {
+		for dec.Remaining() {
+			buf, idx := dec.DelBytesAny([][]byte{[]byte("\r\n"), []byte(";"), []byte("\n")}, true)
+			bufs = append(bufs, buf)
+			which = append(which, idx)
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte("foo"), []byte("bar"), []byte("baz")}, bufs)
+	assert.Equal(t, []int{0, 1, 2}, which)
+
+	err = Decode([]byte("foo"), func(dec *Decoder) error {
+		dec.DelStringAny(nil, false)
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrEmptyDelimiter)
+
+	err = Decode([]byte("foo"), func(dec *Decoder) error {
+		dec.DelBytesAny([][]byte{[]byte(";"), nil}, false)
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrEmptyDelimiter)
+
+	err = Decode([]byte("foo"), func(dec *Decoder) error {
+		dec.DelStringAny([]string{";", "\n"}, false)
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrBufferTooShort)
+
+	err = Decode([]byte("foo\r"), func(dec *Decoder) error {
+		dec.DelStringAny([]string{";", "\r\n"}, false)
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrBufferTooShort)
+}
+
+func TestDecodeList(t *testing.T) {
+	var num int
+	var list []uint16
+	err := Decode([]byte{3, 0, 1, 0, 2, 0, 3}, func(dec *Decoder) error {
+		num = dec.List(3, func(dec *Decoder, i int) error {
+			list = append(list, dec.Uint16())
+			return nil
+		})
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, num)
+	assert.Equal(t, []uint16{1, 2, 3}, list)
+
+	err = Decode([]byte{3, 0, 1, 0, 2, 0, 3}, func(dec *Decoder) error {
+		dec.List(2, func(dec *Decoder, i int) error {
+			panic("unexpected")
+		})
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrListTooLong)
+
+	err = Decode([]byte{3, 0, 1, 0, 2, 0, 3}, func(dec *Decoder) error {
+		dec.List(-1, func(dec *Decoder, i int) error {
+			panic("unexpected")
+		})
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrInvalidLength)
+
+	var calls int
+	err = Decode([]byte{3, 0, 1, 0, 2, 0, 3}, func(dec *Decoder) error {
+		dec.List(3, func(dec *Decoder, i int) error {
+			calls++
+			return io.EOF
+		})
+		return nil
+	})
+	assert.Equal(t, io.EOF, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestDecodeWhile(t *testing.T) {
+	var list []uint16
+	err := Decode([]byte{0, 1, 0, 2, 0, 3}, func(dec *Decoder) error {
+		dec.While(func(dec *Decoder) error {
+			list = append(list, dec.Uint16())
+			return nil
+		})
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []uint16{1, 2, 3}, list)
+
+	err = Decode([]byte{0, 1, 0}, func(dec *Decoder) error {
+		dec.While(func(dec *Decoder) error {
+			dec.Uint16()
+			return nil
+		})
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrBufferTooShort)
+
+	err = Decode([]byte{0, 1}, func(dec *Decoder) error {
+		dec.While(func(dec *Decoder) error {
+			return io.EOF
+		})
+		return nil
+	})
+	assert.Equal(t, io.EOF, err)
+
+	err = Decode([]byte{0, 1}, func(dec *Decoder) error {
+		dec.While(func(dec *Decoder) error {
+			return nil
+		})
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrNoProgress)
+}
+
+func TestDecodePeek(t *testing.T) {
+	err := Decode([]byte{1, 2, 3}, func(dec *Decoder) error {
+		assert.Equal(t, uint8(1), dec.PeekUint8())
+		assert.Equal(t, uint16(0x0102), dec.PeekUint16())
+		assert.Equal(t, []byte{1, 2, 3}, dec.PeekBytes(3))
+		dec.UseLittleEndian()
+		assert.Equal(t, uint16(0x0201), dec.PeekUint16())
+		assert.Equal(t, uint64(0x0201), dec.PeekUint(2))
+		assert.Equal(t, 3, dec.Length())
+		dec.Skip(3)
+		return nil
+	})
+	assert.NoError(t, err)
+
+	err = Decode([]byte{1}, func(dec *Decoder) error {
+		dec.PeekUint16()
+		assert.Equal(t, 1, dec.Length())
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrBufferTooShort)
+}
+
+func TestDecodeRewind(t *testing.T) {
+	var num uint16
+	err := Decode([]byte{1, 2, 3}, func(dec *Decoder) error {
+		cp := dec.Mark()
+		dec.Uint32()
+		assert.ErrorIs(t, dec.Error(), ErrBufferTooShort)
+		dec.Rewind(cp)
+		assert.NoError(t, dec.Error())
+		num = dec.Uint16()
+		dec.Uint8()
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(0x0102), num)
+
+	dec := NewDecoder([]byte{1, 2})
+	cp := dec.Mark()
+	dec.Reset([]byte{1, 2})
+	dec.Uint8()
+	dec.Rewind(cp)
+	assert.Equal(t, ErrInvalidCheckpoint, dec.Error())
+	assert.Equal(t, 1, dec.Length())
+
+	dec.Reset(nil)
+	dec.Rewind(Checkpoint{})
+	assert.Equal(t, ErrInvalidCheckpoint, dec.Error())
+}
+
+func TestDecodeLimit(t *testing.T) {
+	var num1, num2 uint8
+	var offset int
+	err := Decode([]byte{2, 1, 2, 3}, func(dec *Decoder) error {
+		dec.Limit(int(dec.Uint8()), func(dec *Decoder) error {
+			dec.Uint8()
+			offset = dec.Offset()
+			num1 = dec.Uint8()
+			dec.Uint8()
+			return nil
+		})
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrBufferTooShort)
+	assert.Equal(t, 2, offset)
+	assert.Equal(t, uint8(2), num1)
+
+	err = Decode([]byte{2, 1, 2, 3}, func(dec *Decoder) error {
+		dec.Limit(int(dec.Uint8()), func(dec *Decoder) error {
+			num1 = dec.Uint8()
+			num2 = dec.Uint8()
+			return nil
+		})
+		offset = dec.Offset()
+		dec.Uint8()
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(1), num1)
+	assert.Equal(t, uint8(2), num2)
+	assert.Equal(t, 3, offset)
+
+	err = Decode([]byte{2, 1, 2, 3}, func(dec *Decoder) error {
+		dec.Limit(int(dec.Uint8()), func(dec *Decoder) error {
+			dec.Uint8()
+			return nil
+		})
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrRemainingBytes)
+
+	err = Decode([]byte{2, 1, 2, 3}, func(dec *Decoder) error {
+		dec.Limit(int(dec.Uint8()), func(dec *Decoder) error {
+			return io.EOF
+		})
+		return nil
+	})
+	assert.Equal(t, io.EOF, err)
+
+	err = Decode([]byte{4, 1, 2, 3}, func(dec *Decoder) error {
+		dec.Limit(int(dec.Uint8()), func(dec *Decoder) error {
+			panic("unexpected")
+		})
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrBufferTooShort)
+}
+
+type shortWriter struct{}
+
+func (shortWriter) Write(p []byte) (int, error) {
+	return len(p) / 2, nil
+}
+
+func TestDecodeCopyTo(t *testing.T) {
+	var out bytes.Buffer
+	err := Decode([]byte("\x03foo"), func(dec *Decoder) error {
+		dec.CopyTo(&out, int(dec.Uint8()))
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "foo", out.String())
+
+	err = Decode([]byte("\x04foo"), func(dec *Decoder) error {
+		dec.CopyTo(&out, int(dec.Uint8()))
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrBufferTooShort)
+
+	err = Decode([]byte("foo"), func(dec *Decoder) error {
+		dec.CopyTo(shortWriter{}, 3)
+		return nil
+	})
+	assert.ErrorIs(t, err, io.ErrShortWrite)
+}
+
+func TestDecodeRead(t *testing.T) {
+	var out []byte
+	err := Decode([]byte("\x03foobar"), func(dec *Decoder) error {
+		dec.Uint8()
+		var err error
+		out, err = io.ReadAll(dec)
+		return err
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("foobar"), out)
+
+	err = Decode([]byte("foo"), func(dec *Decoder) error {
+		buf := make([]byte, 2)
+		n, err := dec.Read(buf)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, n)
+		assert.Equal(t, []byte("fo"), buf)
+		n, err = dec.Read(buf)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, n)
+		n, err = dec.Read(buf)
+		assert.Equal(t, io.EOF, err)
+		assert.Equal(t, 0, n)
+		return nil
+	})
+	assert.NoError(t, err)
+
+	err = Decode([]byte("foo"), func(dec *Decoder) error {
+		dec.Skip(4)
+		n, err := dec.Read(make([]byte, 2))
+		assert.ErrorIs(t, err, ErrBufferTooShort)
+		assert.Equal(t, 0, n)
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrBufferTooShort)
+}
+
+func TestDecodeReadByte(t *testing.T) {
+	var num uint64
+	err := Decode([]byte("\xac\x02"), func(dec *Decoder) error {
+		var err error
+		num, err = binary.ReadUvarint(dec)
+		return err
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(300), num)
+
+	err = Decode([]byte("ab"), func(dec *Decoder) error {
+		assert.Equal(t, ErrInvalidUnread, dec.UnreadByte())
+
+		b, err := dec.ReadByte()
+		assert.NoError(t, err)
+		assert.Equal(t, byte('a'), b)
+
+		assert.NoError(t, dec.UnreadByte())
+		assert.Equal(t, ErrInvalidUnread, dec.UnreadByte())
+
+		b, err = dec.ReadByte()
+		assert.NoError(t, err)
+		assert.Equal(t, byte('a'), b)
+
+		dec.Uint8()
+		assert.Equal(t, ErrInvalidUnread, dec.UnreadByte())
+
+		b, err = dec.ReadByte()
+		assert.Equal(t, io.EOF, err)
+		assert.Equal(t, byte(0), b)
+
+		return nil
+	})
+	assert.NoError(t, err)
+
+	err = DecodeVec([][]byte{{'a'}, {'b'}, {'c'}}, func(dec *Decoder) error {
+		b, err := dec.ReadByte()
+		assert.NoError(t, err)
+		assert.Equal(t, byte('a'), b)
+
+		assert.Equal(t, uint8('b'), dec.Uint8())
+		assert.Equal(t, ErrInvalidUnread, dec.UnreadByte())
+		assert.Equal(t, 2, dec.Offset())
+
+		b, err = dec.ReadByte()
+		assert.NoError(t, err)
+		assert.Equal(t, byte('c'), b)
+
+		assert.NoError(t, dec.UnreadByte())
+		assert.Equal(t, 2, dec.Offset())
+
+		b, err = dec.ReadByte()
+		assert.NoError(t, err)
+		assert.Equal(t, byte('c'), b)
+
+		return nil
+	})
+	assert.NoError(t, err)
+}
+
+func TestDecodeFixed(t *testing.T) {
+	data := []byte{
+		0x00, 0x01, 0x80, 0x00,
+		0xFF, 0xFE, 0x80, 0x00,
+		0x00, 0x40, 0x00, 0x00,
+		0x00, 0x00, 0x01, 0x00,
+	}
+
+	var nums []float64
+	err := Decode(data, func(dec *Decoder) error {
+		nums = append(nums, dec.FixedQ16())
+		nums = append(nums, dec.FixedQ16())
+		nums = append(nums, dec.Fixed(8, 24))
+		dec.UseLittleEndian()
+		nums = append(nums, dec.FixedQ16())
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{1.5, -1.5, 0.25, 1}, nums)
+
+	err = Decode(data, func(dec *Decoder) error {
+		dec.Fixed(4, 8)
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrInvalidSize)
+}
+
+func TestDecodeFloatChecked(t *testing.T) {
+	var f32 float32
+	var f64 float64
+	err := Decode([]byte{
+		0x3F, 0xC0, 0, 0,
+		0xC0, 0x04, 0, 0, 0, 0, 0, 0,
+	}, func(dec *Decoder) error {
+		f32 = dec.Float32Checked()
+		f64 = dec.Float64Checked()
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, float32(1.5), f32)
+	assert.Equal(t, -2.5, f64)
+
+	for _, num := range []float64{math.NaN(), math.Inf(1), math.Inf(-1)} {
+		buf, _, err := Encode(nil, func(enc *Encoder) error {
+			enc.Float32(float32(num))
+			enc.Float64(num)
+			return nil
+		})
+		assert.NoError(t, err)
+
+		err = Decode(buf, func(dec *Decoder) error {
+			dec.Float32()
+			dec.Float64()
+			return nil
+		})
+		assert.NoError(t, err)
+
+		err = Decode(buf, func(dec *Decoder) error {
+			dec.Float32Checked()
+			return nil
+		})
+		assert.ErrorIs(t, err, ErrNonFiniteFloat)
+
+		err = Decode(buf[4:], func(dec *Decoder) error {
+			dec.Float64Checked()
+			return nil
+		})
+		assert.ErrorIs(t, err, ErrNonFiniteFloat)
+	}
+}
+
+func TestDecodeValidateUTF8(t *testing.T) {
+	valid := []byte("\x03héfoo;")
+	invalid := []byte("\x02\xC3\x28foo;")
+
+	var str1, str2 string
+	err := Decode(valid, func(dec *Decoder) error {
+		dec.ValidateUTF8(true)
+		str1 = dec.FixString(1, false)
+		str2 = dec.DelString(";", false)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "hé", str1)
+	assert.Equal(t, "foo", str2)
+
+	err = Decode(invalid, func(dec *Decoder) error {
+		dec.FixString(1, false)
+		dec.DelString(";", false)
+		return nil
+	})
+	assert.NoError(t, err)
+
+	err = Decode(invalid, func(dec *Decoder) error {
+		dec.ValidateUTF8(true)
+		dec.FixString(1, false)
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrInvalidUTF8)
+
+	err = Decode(invalid[3:], func(dec *Decoder) error {
+		dec.ValidateUTF8(true)
+		dec.DelString(";", false)
+		dec.ValidateUTF8(false)
+		dec.TailString(false)
+		return nil
+	})
+	assert.NoError(t, err)
+}
+
+func TestDecodeString(t *testing.T) {
+	str := "\x03foo"
+
+	var res string
+	err := DecodeString(str, func(dec *Decoder) error {
+		res = dec.VarString(false)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "foo", res)
+
+	err = DecodeString(str, func(dec *Decoder) error {
+		dec.Uint8()
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrRemainingBytes)
+
+	err = DecodeString("", func(dec *Decoder) error {
+		dec.Uint8()
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrBufferTooShort)
+
+	allocs := testing.AllocsPerRun(10, func() {
+		_ = DecodeString(str, func(dec *Decoder) error {
+			res = dec.VarString(false)
+			return nil
+		})
+	})
+	if !safeMode {
+		assert.Equal(t, 0.0, allocs)
+	}
+}
+
+func TestDecodeVec(t *testing.T) {
+	var num uint32
+	var str string
+	fn := func(dec *Decoder) error {
+		num = dec.Uint32()
+		str = dec.VarString(true)
+		return nil
+	}
+
+	for _, bufs := range [][][]byte{
+		{{0, 0, 0, 1, 3, 'f', 'o', 'o'}},
+		{nil, {0, 0, 0, 1, 3, 'f', 'o', 'o'}, {}},
+		{{0, 0}, {0, 1, 3, 'f'}, {'o', 'o'}},
+		{{0}, {0}, {0}, {1}, {3}, {'f'}, {'o'}, {'o'}},
+	} {
+		num, str = 0, ""
+		err := DecodeVec(bufs, fn)
+		assert.NoError(t, err)
+		assert.Equal(t, uint32(1), num)
+		assert.Equal(t, "foo", str)
+	}
+
+	err := DecodeVec([][]byte{{0, 0}, {0, 1, 3}}, fn)
+	assert.ErrorIs(t, err, ErrBufferTooShort)
+
+	err = DecodeVec([][]byte{{0, 0}, {0, 1, 0}, {1}}, fn)
+	assert.ErrorIs(t, err, ErrRemainingBytes)
+
+	err = DecodeVec(nil, fn)
+	assert.ErrorIs(t, err, ErrBufferTooShort)
+
+	buf := []byte{0, 0, 0, 1, 3, 'f', 'o', 'o', 0, 0, 0, 2, 3, 'b', 'a', 'r'}
+	var foo, bar []byte
+	err = DecodeVec([][]byte{buf[:8], buf[8:]}, func(dec *Decoder) error {
+		dec.Uint32()
+		foo = dec.VarBytes(false)
+		dec.Uint32()
+		bar = dec.VarBytes(false)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "foo", string(foo))
+	assert.Equal(t, "bar", string(bar))
+	if !safeMode {
+		assert.True(t, &foo[0] == &buf[5])
+		assert.True(t, &bar[0] == &buf[13])
+	}
+
+	bufs := [][]byte{buf[:8]}
+	allocs := testing.AllocsPerRun(10, func() {
+		_ = DecodeVec(bufs, func(dec *Decoder) error {
+			dec.Uint32()
+			dec.VarString(false)
+			return nil
+		})
+	})
+	if !safeMode {
+		assert.Equal(t, 0.0, allocs)
+	}
+}
+
+func TestDecodeVecSplit(t *testing.T) {
+	read := func(dec *Decoder) []interface{} {
+		var list []interface{}
+		add := func(v ...interface{}) {
+			list = append(list, v...)
+		}
+		dec.Skip(3)
+		add(dec.Bool(), dec.Bool(), dec.Int8(), dec.Int8(), dec.Int16(), dec.Int16())
+		add(dec.Int32(), dec.Int32(), dec.Int64(), dec.Int64(), dec.Int(4))
+		add(dec.Uint8(), dec.Uint16(), dec.Uint32(), dec.Uint64())
+		add(dec.Float32(), dec.Float64(), dec.VarInt(), dec.VarUint(), dec.TimeUnix())
+		add(dec.String(3, false), dec.Bytes(3, false))
+		add(dec.FixString(1, false), dec.FixBytes(1, false))
+		add(dec.VarString(false), dec.VarBytes(false))
+		add(dec.DelString("\x00", false), dec.DelBytes([]byte{0}, false))
+		add(dec.Length(), dec.Tail(false))
+		return list
+	}
+
+	var expected []interface{}
+	err := Decode(dummy, func(dec *Decoder) error {
+		expected = read(dec)
+		return nil
+	})
+	assert.NoError(t, err)
+
+	for i := 0; i <= len(dummy); i++ {
+		for j := i; j <= len(dummy); j += 7 {
+			var list []interface{}
+			err = DecodeVec([][]byte{dummy[:i], dummy[i:j], dummy[j:]}, func(dec *Decoder) error {
+				list = read(dec)
+				return nil
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, expected, list, "%d %d", i, j)
+		}
+	}
+
+	msg, _, err := Encode(nil, func(enc *Encoder) error {
+		enc.Uint32(1)
+		enc.VarUint(1 << 40)
+		enc.OrderedString("foo")
+		enc.Uint16(2)
+		enc.Uint16(3)
+		enc.Uint32(4)
+		enc.HMAC(sha256.New, []byte("secret"))
+		return nil
+	})
+	assert.NoError(t, err)
+
+	for i := 0; i <= len(msg); i++ {
+		var list []interface{}
+		var offset int
+		err = DecodeVec([][]byte{msg[:i], msg[i:]}, func(dec *Decoder) error {
+			cp := dec.Mark()
+			dec.Skip(7)
+			dec.Rewind(cp)
+			list = append(list, dec.Uint32(), dec.VarUint(), dec.OrderedString(false))
+			list = append(list, dec.PeekUint16())
+			dec.Limit(4, func(dec *Decoder) error {
+				list = append(list, dec.Uint16(), dec.Uint16())
+				return nil
+			})
+			list = append(list, dec.Uint32())
+			offset = dec.Offset()
+			dec.HMAC(sha256.New, []byte("secret"))
+			return nil
+		})
+		assert.NoError(t, err, i)
+		assert.Equal(t, []interface{}{uint32(1), uint64(1 << 40), "foo", uint16(2), uint16(2), uint16(3), uint32(4)}, list, i)
+		assert.Equal(t, len(msg)-sha256.Size, offset, i)
+	}
+}
+
+func TestDecoderDecode(t *testing.T) {
+	dec := NewDecoder(nil)
+	dec.UseLittleEndian()
+
+	var num uint16
+	err := dec.Decode([]byte{0, 1}, func(dec *Decoder) error {
+		num = dec.Uint16()
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(1), num)
+
+	err = dec.Decode([]byte{0, 1, 2}, func(dec *Decoder) error {
+		dec.Uint16()
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrRemainingBytes)
+
+	err = dec.Decode([]byte{0}, func(dec *Decoder) error {
+		dec.Uint16()
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrBufferTooShort)
+	assert.NoError(t, dec.Error())
+	assert.Zero(t, dec.Length())
+
+	buf := []byte{1, 2, 3, 4}
+	assert.Equal(t, 0.0, testing.AllocsPerRun(10, func() {
+		_ = dec.Decode(buf, func(dec *Decoder) error {
+			dec.Uint32()
+			return nil
+		})
+	}))
+}
+
+func TestDecoderSetMaxLength(t *testing.T) {
+	for _, fn := range []func(dec *Decoder){
+		func(dec *Decoder) { dec.VarString(true) },
+		func(dec *Decoder) { dec.VarBytes(true) },
+		func(dec *Decoder) { dec.FixString(1, true) },
+		func(dec *Decoder) { dec.FixBytes(1, true) },
+		func(dec *Decoder) { dec.HexBytes(1) },
+	} {
+		err := Decode([]byte{4, 'a', 'b', 'c', 'd'}, func(dec *Decoder) error {
+			dec.SetMaxLength(4)
+			fn(dec)
+			return nil
+		})
+		assert.NoError(t, err)
+
+		err = Decode([]byte{4, 'a', 'b', 'c', 'd'}, func(dec *Decoder) error {
+			dec.SetMaxLength(3)
+			fn(dec)
+			assert.Equal(t, 4, dec.Length())
+			return nil
+		})
+		assert.ErrorIs(t, err, ErrLengthLimit)
+	}
+
+	err := Decode([]byte{0x80, 0x80, 0x80, 0x80, 0x08}, func(dec *Decoder) error {
+		dec.SetMaxLength(1024)
+		dec.VarBytes(true)
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrLengthLimit)
+
+	err = DecodeWith([]byte{4, 'a', 'b', 'c', 'd'}, Options{MaxLength: 3}, func(dec *Decoder) error {
+		dec.VarString(false)
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrLengthLimit)
+
+	dec := NewDecoder(nil)
+	dec.SetMaxLength(1)
+	dec.Reset([]byte{2, 'a', 'b'})
+	assert.Equal(t, "ab", dec.VarString(false))
+	assert.NoError(t, dec.Error())
+}
+
+func TestDecoderDetailErrors(t *testing.T) {
+	err := Decode([]byte{1, 2, 3}, func(dec *Decoder) error {
+		dec.Uint8()
+		dec.Uint32()
+		return nil
+	})
+	assert.Equal(t, &DecodeError{Offset: 1, Op: "uint32", Length: 4, Err: ErrBufferTooShort}, err)
+	assert.EqualError(t, err, "uint32 at offset 1 (length 4): buffer too short")
+	assert.True(t, errors.Is(err, ErrBufferTooShort))
+
+	err = Decode([]byte{1, 3, 'f', 'o'}, func(dec *Decoder) error {
+		dec.Skip(1)
+		dec.VarString(false)
+		return nil
+	})
+	assert.Equal(t, &DecodeError{Offset: 2, Op: "varstring", Length: 3, Err: ErrBufferTooShort}, err)
+
+	err = Decode([]byte{0x80}, func(dec *Decoder) error {
+		dec.VarUint()
+		return nil
+	})
+	assert.Equal(t, &DecodeError{Offset: 0, Op: "varuint", Err: ErrBufferTooShort}, err)
+
+	err = Decode([]byte{1, 2, 3}, func(dec *Decoder) error {
+		dec.Uint(3)
+		return nil
+	})
+	assert.Equal(t, &DecodeError{Offset: 0, Op: "uint", Length: 3, Err: ErrInvalidSize}, err)
+
+	err = Decode([]byte{1, 2}, func(dec *Decoder) error {
+		dec.Uint8()
+		dec.StrictBool()
+		return nil
+	})
+	assert.Equal(t, &DecodeError{Offset: 1, Op: "strictbool", Length: 1, Err: ErrInvalidBool}, err)
+
+	err = Decode([]byte{3, 1, 2, 3}, func(dec *Decoder) error {
+		dec.SetMaxLength(2)
+		dec.VarBytes(false)
+		return nil
+	})
+	assert.Equal(t, &DecodeError{Offset: 1, Op: "varbytes", Length: 3, Err: ErrLengthLimit}, err)
+
+	err = Decode([]byte{1, 2, 3}, func(dec *Decoder) error {
+		dec.DetailErrors(false)
+		dec.Uint8()
+		dec.Uint32()
+		return nil
+	})
+	assert.Equal(t, ErrBufferTooShort, err)
+
+	dec := NewDecoder([]byte{1, 2, 3})
+	dec.Uint32()
+	assert.Equal(t, ErrBufferTooShort, dec.Error())
+
+	dec = NewDecoder([]byte{1, 2, 3})
+	dec.DetailErrors(true)
+	dec.Uint16LE()
+	dec.Uint16LE()
+	assert.Equal(t, &DecodeError{Offset: 2, Op: "uint16le", Length: 2, Err: ErrBufferTooShort}, dec.Error())
+}
+
+func BenchmarkDecode(b *testing.B) {
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		err := Decode(dummy, func(dec *Decoder) error {
+			dec.Skip(3)
+			dec.Bool()
+			dec.Bool()
+			dec.Int8()
+			dec.Int8()
+			dec.Int16()
+			dec.Int16()
+			dec.Int32()
+			dec.Int32()
+			dec.Int64()
+			dec.Int64()
+			dec.Int(4)
+			dec.Uint8()
+			dec.Uint16()
+			dec.Uint32()
+			dec.Uint64()
+			dec.Float32()
+			dec.Float64()
+			dec.VarInt()
+			dec.VarUint()
+			dec.String(3, false)
+			dec.Bytes(3, false)
+			dec.FixString(1, false)
+			dec.FixBytes(1, false)
+			dec.VarString(false)
+			dec.VarBytes(false)
+			dec.DelString("\x00", false)
+			dec.DelBytes([]byte{0}, false)
+			dec.Tail(false)
+			return nil
+		})
+		if err != nil {
+			panic(err)
+		}
+	}
+}
+
+func BenchmarkDecodeString(b *testing.B) {
+	benchmarkDecodeString(b, false)
+}
+
+func BenchmarkDecodeStringValidateUTF8(b *testing.B) {
+	benchmarkDecodeString(b, true)
+}
+
+func benchmarkDecodeString(b *testing.B, validate bool) {
+	buf := []byte(strings.Repeat("hello wörld! ", 10))
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(buf)))
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		err := Decode(buf, func(dec *Decoder) error {
+			dec.ValidateUTF8(validate)
+			dec.TailString(false)
 			return nil
 		})
 		if err != nil {
@@ -0,0 +1,158 @@
+package fpack
+
+import "math"
+
+// DeltaVarUintSlice writes a variable count prefixed slice of unsigned
+// integers. The first value is written as a variable unsigned integer and the
+// following values as variable signed integer (zigzag) deltas to their
+// predecessor. Deltas that do not fit into a signed integer result in
+// ErrNumberOverflow.
+func (e *Encoder) DeltaVarUintSlice(nums []uint64) {
+	// write count
+	e.VarUint(uint64(len(nums)))
+	if len(nums) == 0 {
+		return
+	}
+
+	// write first
+	e.VarUint(nums[0])
+
+	// write deltas
+	for i := 1; i < len(nums) && e.err == nil; i++ {
+		prev, num := nums[i-1], nums[i]
+		if num >= prev {
+			if num-prev > math.MaxInt64 {
+				e.err = ErrNumberOverflow
+				return
+			}
+			e.VarInt(int64(num - prev))
+		} else {
+			if prev-num > 1<<63 {
+				e.err = ErrNumberOverflow
+				return
+			}
+			e.VarInt(-int64(prev - num))
+		}
+	}
+}
+
+// DeltaVarIntSlice writes a variable count prefixed slice of signed integers.
+// The first value and the following deltas to their predecessor are written as
+// variable signed integers (zigzag). Deltas that do not fit into a signed
+// integer result in ErrNumberOverflow.
+func (e *Encoder) DeltaVarIntSlice(nums []int64) {
+	// write count
+	e.VarUint(uint64(len(nums)))
+	if len(nums) == 0 {
+		return
+	}
+
+	// write first
+	e.VarInt(nums[0])
+
+	// write deltas
+	for i := 1; i < len(nums) && e.err == nil; i++ {
+		prev, num := nums[i-1], nums[i]
+		delta := num - prev
+		if (num^prev)&(num^delta) < 0 {
+			e.err = ErrNumberOverflow
+			return
+		}
+		e.VarInt(delta)
+	}
+}
+
+// DeltaVarUintSlice reads a slice written by the encoders DeltaVarUintSlice
+// method. It sets ErrListTooLong if the count exceeds the specified maximum and
+// ErrNumberOverflow if the reconstructed values overflow.
+func (d *Decoder) DeltaVarUintSlice(maxLen int) []uint64 {
+	// read count
+	num := d.varuint("deltavaruintslice")
+	if d.err != nil {
+		return nil
+	}
+
+	// check count
+	if !d.count("deltavaruintslice", num, maxLen) {
+		return nil
+	}
+
+	// prepare slice
+	nums := make([]uint64, 0, num)
+	if num == 0 {
+		return nums
+	}
+
+	// read first
+	prev := d.varuint("deltavaruintslice")
+	nums = append(nums, prev)
+
+	// read deltas
+	for i := uint64(1); i < num && d.err == nil; i++ {
+		start := d.Offset()
+		delta := d.varint("deltavaruintslice")
+		var next uint64
+		if delta >= 0 {
+			next = prev + uint64(delta)
+			if next < prev {
+				d.failAt(ErrNumberOverflow, "deltavaruintslice", start, d.Offset()-start)
+			}
+		} else {
+			sub := uint64(-delta)
+			if sub > prev {
+				d.failAt(ErrNumberOverflow, "deltavaruintslice", start, d.Offset()-start)
+			}
+			next = prev - sub
+		}
+		nums = append(nums, next)
+		prev = next
+	}
+	if d.err != nil {
+		return nil
+	}
+
+	return nums
+}
+
+// DeltaVarIntSlice reads a slice written by the encoders DeltaVarIntSlice
+// method. It sets ErrListTooLong if the count exceeds the specified maximum and
+// ErrNumberOverflow if the reconstructed values overflow.
+func (d *Decoder) DeltaVarIntSlice(maxLen int) []int64 {
+	// read count
+	num := d.varuint("deltavarintslice")
+	if d.err != nil {
+		return nil
+	}
+
+	// check count
+	if !d.count("deltavarintslice", num, maxLen) {
+		return nil
+	}
+
+	// prepare slice
+	nums := make([]int64, 0, num)
+	if num == 0 {
+		return nums
+	}
+
+	// read first
+	prev := d.varint("deltavarintslice")
+	nums = append(nums, prev)
+
+	// read deltas
+	for i := uint64(1); i < num && d.err == nil; i++ {
+		start := d.Offset()
+		delta := d.varint("deltavarintslice")
+		next := prev + delta
+		if (prev^next)&(delta^next) < 0 {
+			d.failAt(ErrNumberOverflow, "deltavarintslice", start, d.Offset()-start)
+		}
+		nums = append(nums, next)
+		prev = next
+	}
+	if d.err != nil {
+		return nil
+	}
+
+	return nums
+}
@@ -0,0 +1,101 @@
+package fpack
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeltaVarUintSlice(t *testing.T) {
+	table := [][]uint64{
+		{},
+		{42},
+		{1000, 1001, 1003, 1010},
+		{5, 3, 10, 0, 1 << 62, 1 << 63, math.MaxUint64, 1 << 63},
+	}
+
+	for _, nums := range table {
+		buf, _, err := Encode(nil, func(enc *Encoder) error {
+			enc.DeltaVarUintSlice(nums)
+			return nil
+		})
+		assert.NoError(t, err)
+
+		var res []uint64
+		err = Decode(buf, func(dec *Decoder) error {
+			res = dec.DeltaVarUintSlice(10)
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, nums, res)
+	}
+
+	buf, _, err := Encode(nil, func(enc *Encoder) error {
+		enc.DeltaVarUintSlice([]uint64{1000, 1001, 1003, 1010})
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{4, 0xE8, 0x07, 2, 4, 14}, buf)
+
+	_, _, err = Encode(nil, func(enc *Encoder) error {
+		enc.DeltaVarUintSlice([]uint64{0, math.MaxUint64})
+		return nil
+	})
+	assert.Equal(t, ErrNumberOverflow, err)
+
+	err = Decode([]byte{4, 0xE8, 0x07, 2, 4, 14}, func(dec *Decoder) error {
+		dec.DeltaVarUintSlice(3)
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrListTooLong)
+
+	err = Decode([]byte{2, 1, 3}, func(dec *Decoder) error {
+		dec.DeltaVarUintSlice(3)
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrNumberOverflow)
+
+	err = Decode([]byte{2, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0x01, 2}, func(dec *Decoder) error {
+		dec.DeltaVarUintSlice(3)
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrNumberOverflow)
+}
+
+func TestDeltaVarIntSlice(t *testing.T) {
+	table := [][]int64{
+		{},
+		{-42},
+		{-3, -2, 0, 5, 4, -10},
+		{math.MinInt64, -1, math.MaxInt64 - 1, 0},
+	}
+
+	for _, nums := range table {
+		buf, _, err := Encode(nil, func(enc *Encoder) error {
+			enc.DeltaVarIntSlice(nums)
+			return nil
+		})
+		assert.NoError(t, err)
+
+		var res []int64
+		err = Decode(buf, func(dec *Decoder) error {
+			res = dec.DeltaVarIntSlice(10)
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, nums, res)
+	}
+
+	_, _, err := Encode(nil, func(enc *Encoder) error {
+		enc.DeltaVarIntSlice([]int64{math.MinInt64, math.MaxInt64})
+		return nil
+	})
+	assert.Equal(t, ErrNumberOverflow, err)
+
+	err = Decode([]byte{2, 0xFE, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0x01, 2}, func(dec *Decoder) error {
+		dec.DeltaVarIntSlice(3)
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrNumberOverflow)
+}
@@ -0,0 +1,102 @@
+package fpack
+
+import "errors"
+
+// ErrInvalidIndex is returned if a decoded dictionary index is unknown.
+var ErrInvalidIndex = errors.New("invalid index")
+
+// Dict is an append-only string dictionary used to encode repeated strings as
+// variable indexes into a table. The zero value is an empty dictionary ready
+// to use.
+//
+// The decoder must load the table before resolving strings. If the table
+// should precede the strings in a single message, the dictionary can be
+// populated by running the encoding function with Measure before encoding.
+type Dict struct {
+	index map[string]int
+	list  []string
+}
+
+// NewDict creates and returns a new dictionary.
+func NewDict() *Dict {
+	return &Dict{
+		index: map[string]int{},
+	}
+}
+
+// Len returns the number of strings in the dictionary.
+func (d *Dict) Len() int {
+	return len(d.list)
+}
+
+func (d *Dict) add(str string) int {
+	// ensure index
+	if d.index == nil {
+		d.index = map[string]int{}
+	}
+
+	// add string
+	idx := len(d.list)
+	d.index[str] = idx
+	d.list = append(d.list, str)
+
+	return idx
+}
+
+// DictString writes a string as a variable index into the provided dictionary.
+// The string is added to the dictionary on first use.
+func (e *Encoder) DictString(dict *Dict, str string) {
+	// skip if errored
+	if e.err != nil {
+		return
+	}
+
+	// get or add index
+	idx, ok := dict.index[str]
+	if !ok {
+		idx = dict.add(str)
+	}
+
+	// write index
+	e.VarUint(uint64(idx))
+}
+
+// DictTable writes the provided dictionary as a variable count prefixed list of
+// variable length prefixed strings.
+func (e *Encoder) DictTable(dict *Dict) {
+	e.List(len(dict.list), func(enc *Encoder, i int) {
+		enc.VarString(dict.list[i])
+	})
+}
+
+// DictTable reads a dictionary table and appends the strings to the provided
+// dictionary. The strings are always cloned. It sets ErrListTooLong if the
+// count exceeds the specified maximum.
+func (d *Decoder) DictTable(dict *Dict, maxLen int) {
+	d.List(maxLen, func(dec *Decoder, i int) error {
+		str := dec.VarString(true)
+		if dec.err == nil {
+			dict.add(str)
+		}
+		return nil
+	})
+}
+
+// DictString reads a variable index and returns the string from the provided
+// dictionary. Unknown indexes result in ErrInvalidIndex.
+func (d *Decoder) DictString(dict *Dict) string {
+	// read index
+	start := d.Offset()
+	idx := d.varuint("dictstring")
+	if d.err != nil {
+		return ""
+	}
+
+	// check index
+	if idx >= uint64(len(dict.list)) {
+		d.failAt(ErrInvalidIndex, "dictstring", start, d.Offset()-start)
+		return ""
+	}
+
+	return dict.list[idx]
+}
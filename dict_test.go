@@ -0,0 +1,118 @@
+package fpack
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDict(t *testing.T) {
+	strs := []string{"foo", "bar", "foo", "baz", "bar", "foo"}
+
+	dict := NewDict()
+	records, _, err := Encode(nil, func(enc *Encoder) error {
+		for _, str := range strs {
+			enc.DictString(dict, str)
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0, 1, 0, 2, 1, 0}, records)
+	assert.Equal(t, 3, dict.Len())
+
+	table, _, err := Encode(nil, func(enc *Encoder) error {
+		enc.DictTable(dict)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "\x03\x03foo\x03bar\x03baz", string(table))
+
+	dict = NewDict()
+	err = Decode(table, func(dec *Decoder) error {
+		dec.DictTable(dict, 10)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, dict.Len())
+
+	var res []string
+	err = Decode(records, func(dec *Decoder) error {
+		for dec.Remaining() {
+			res = append(res, dec.DictString(dict))
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, strs, res)
+
+	err = Decode([]byte{3}, func(dec *Decoder) error {
+		dec.DictString(dict)
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrInvalidIndex)
+
+	err = Decode(table, func(dec *Decoder) error {
+		dec.DictTable(NewDict(), 2)
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrListTooLong)
+}
+
+func TestDictZero(t *testing.T) {
+	var dict Dict
+	buf, _, err := Encode(nil, func(enc *Encoder) error {
+		enc.DictString(&dict, "foo")
+		enc.DictString(&dict, "foo")
+		enc.DictTable(&dict)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "\x00\x00\x01\x03foo", string(buf))
+	assert.Equal(t, 1, dict.Len())
+
+	var res Dict
+	err = Decode(buf[2:], func(dec *Decoder) error {
+		dec.DictTable(&res, 10)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, res.Len())
+
+	err = Decode([]byte{0}, func(dec *Decoder) error {
+		assert.Equal(t, "foo", dec.DictString(&res))
+		return nil
+	})
+	assert.NoError(t, err)
+}
+
+func TestDictSingleMessage(t *testing.T) {
+	strs := []string{"foo", "bar", "foo"}
+
+	dict := NewDict()
+	fn := func(enc *Encoder) error {
+		enc.DictTable(dict)
+		for _, str := range strs {
+			enc.DictString(dict, str)
+		}
+		return nil
+	}
+
+	_, err := Measure(fn)
+	assert.NoError(t, err)
+
+	buf, _, err := Encode(nil, fn)
+	assert.NoError(t, err)
+	assert.Equal(t, "\x02\x03foo\x03bar\x00\x01\x00", string(buf))
+
+	var res []string
+	err = Decode(buf, func(dec *Decoder) error {
+		dict := NewDict()
+		dec.DictTable(dict, 10)
+		for dec.Remaining() {
+			res = append(res, dec.DictString(dict))
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, strs, res)
+}
@@ -2,11 +2,17 @@ package fpack
 
 import (
 	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
 	"math"
+	"strings"
 	"sync"
 	"time"
 )
 
+const dynamicSize = 1 << 10
+
 var encoderPool = sync.Pool{
 	New: func() interface{} {
 		return NewEncoder()
@@ -47,7 +53,7 @@ func Measure(fn func(enc *Encoder) error) (int, error) {
 // is run once to assess the length of the buffer and once to encode the data.
 // Any error returned by the callback is returned immediately.
 func Encode(pool *Pool, fn func(enc *Encoder) error) ([]byte, Ref, error) {
-	buf, _, ref, err := encode(pool, nil, false, fn)
+	buf, _, ref, err := encode(Options{Pool: pool}, nil, false, fn)
 	return buf, ref, err
 }
 
@@ -57,16 +63,53 @@ func Encode(pool *Pool, fn func(enc *Encoder) error) ([]byte, Ref, error) {
 // returned immediately. If the provided buffer is too small ErrBufferTooShort
 // is returned.
 func EncodeInto(buf []byte, fn func(enc *Encoder) error) (int, error) {
-	_, n, _, err := encode(nil, buf, true, fn)
+	_, n, _, err := encode(Options{}, buf, true, fn)
 	return n, err
 }
 
-func encode(pool *Pool, buf []byte, withBuf bool, fn func(enc *Encoder) error) ([]byte, int, Ref, error) {
+// MustEncodeInto will encode data into the specified byte slice like
+// EncodeInto. The function cannot return an error. It returns the number of
+// written bytes and true only if the encoding fully succeeded. If the provided
+// buffer is too small, zero and false are returned. Any other encoder error
+// like ErrNumberOverflow causes a panic.
+func MustEncodeInto(buf []byte, fn func(enc *Encoder)) (int, bool) {
+	// encode
+	n, err := EncodeInto(buf, func(enc *Encoder) error {
+		fn(enc)
+		return nil
+	})
+	if err == ErrBufferTooShort {
+		return 0, false
+	} else if err != nil {
+		panic(err)
+	}
+
+	return n, true
+}
+
+// EncodeArena will encode data using the provided encoding function into a
+// buffer obtained from the provided arena. The function is run once to assess
+// the length of the buffer and once to encode the data. The buffer is owned by
+// the arena and released with it. Any error returned by the callback is
+// returned immediately.
+func EncodeArena(arena *Arena, fn func(enc *Encoder) error) ([]byte, error) {
+	buf, _, _, err := encode(Options{Arena: arena}, nil, false, fn)
+	return buf, err
+}
+
+// EncodeBuffer will encode data using the provided encoding function and write
+// it to the provided buffer at its current offset. The function is run once to
+// assess the length of the data and once to encode the data. The buffer is
+// grown upfront and the data is staged and written in chunks of the buffer's
+// allocation size. It returns the number of written bytes. If an error is
+// returned, the buffer may contain partially written data.
+func EncodeBuffer(buf *Buffer, fn func(enc *Encoder) error) (int, error) {
 	// borrow
 	enc := encoderPool.Get().(*Encoder)
 
 	// recycle
 	defer func() {
+		enc.ref.Release()
 		enc.Reset(nil)
 		encoderPool.Put(enc)
 	}()
@@ -74,46 +117,229 @@ func encode(pool *Pool, buf []byte, withBuf bool, fn func(enc *Encoder) error) (
 	// count
 	err := fn(enc)
 	if err != nil {
-		return nil, 0, Ref{}, err
+		return 0, err
 	}
 
 	// check error
 	err = enc.Error()
 	if err != nil {
-		return nil, 0, Ref{}, err
+		return 0, err
 	}
 
 	// get length
 	length := enc.Length()
 
+	// grow buffer
+	start := buf.prepare(length)
+
+	// borrow staging buffer
+	size := buf.alloc
+	if size > length {
+		size = length
+	}
+	stg, ref := buf.pool.Borrow(size, false)
+
+	// prepare encoder
+	enc.Reset(stg[:cap(stg)])
+	enc.snk = buf
+	enc.sbs = start
+	enc.ref = ref
+
+	// encode
+	err = fn(enc)
+	if err != nil {
+		return 0, err
+	}
+
+	// check error
+	err = enc.Error()
+	if err != nil {
+		return 0, err
+	}
+
+	// flush
+	enc.flush()
+
+	// advance offset
+	_, _ = buf.Seek(int64(length), io.SeekCurrent)
+
+	return length, nil
+}
+
+// EncodeAppend will encode data using the provided encoding function and
+// append it to the specified byte slice. The function is run once to assess the
+// length of the data and once to encode the data. The spare capacity of the
+// slice is used if sufficient, otherwise the slice is grown. Any error returned
+// by the callback is returned immediately together with the unchanged slice.
+func EncodeAppend(dst []byte, fn func(enc *Encoder) error) ([]byte, error) {
+	// borrow
+	enc := encoderPool.Get().(*Encoder)
+
+	// recycle
+	defer func() {
+		enc.Reset(nil)
+		encoderPool.Put(enc)
+	}()
+
+	// count
+	err := fn(enc)
+	if err != nil {
+		return dst, err
+	}
+
+	// check error
+	err = enc.Error()
+	if err != nil {
+		return dst, err
+	}
+
+	// grow buffer
+	buf := append(dst, make([]byte, enc.Length())...)
+
+	// reset encoder
+	enc.Reset(buf[len(dst):])
+
+	// encode
+	err = fn(enc)
+	if err != nil {
+		return dst, err
+	}
+
+	// check error
+	err = enc.Error()
+	if err != nil {
+		return dst, err
+	}
+
+	return buf, nil
+}
+
+// EncodeDynamic will encode data using the provided encoding function. Unlike
+// Encode, the function is only run once and the buffer is grown as needed by
+// borrowing larger buffers from the pool. If no pool is provided, the global
+// pool is used. The returned Ref owns the final buffer. Any error returned by
+// the callback is returned immediately.
+func EncodeDynamic(pool *Pool, fn func(enc *Encoder) error) ([]byte, Ref, error) {
+	return encodeDynamic(pool, dynamicSize, -1, fn)
+}
+
+// EncodeSized will encode data using the provided encoding function into a
+// buffer of the declared size. Unlike Encode, the function is only run once
+// and ErrSizeMismatch is returned if it writes more or fewer bytes than
+// declared. If no pool is provided, the global pool is used. The returned Ref
+// owns the buffer. Any error returned by the callback is returned immediately.
+func EncodeSized(pool *Pool, size int, fn func(enc *Encoder) error) ([]byte, Ref, error) {
+	// check size
+	if size < 0 {
+		return nil, Ref{}, ErrInvalidSize
+	}
+
+	return encodeDynamic(pool, size, size, fn)
+}
+
+func encodeDynamic(pool *Pool, size, expected int, fn func(enc *Encoder) error) ([]byte, Ref, error) {
+	// get pool
+	if pool == nil {
+		pool = Global()
+	}
+
+	// borrow
+	enc := encoderPool.Get().(*Encoder)
+
+	// recycle
+	defer func() {
+		enc.Reset(nil)
+		encoderPool.Put(enc)
+	}()
+
+	// prepare encoder
+	buf, ref := pool.Borrow(size, false)
+	enc.Reset(buf[:cap(buf)])
+	enc.dyn = pool
+	enc.ref = ref
+
+	// encode
+	err := fn(enc)
+	if err == nil {
+		err = enc.Error()
+	}
+	if err == nil && expected >= 0 && enc.Offset() != expected {
+		err = ErrSizeMismatch
+	}
+	if err != nil {
+		enc.ref.Release()
+		return nil, Ref{}, err
+	}
+
+	return enc.org[:enc.Offset()], enc.ref, nil
+}
+
+func encode(opts Options, buf []byte, withBuf bool, fn func(enc *Encoder) error) ([]byte, int, Ref, error) {
+	// borrow
+	enc := encoderPool.Get().(*Encoder)
+
+	// recycle
+	defer func() {
+		enc.Reset(nil)
+		encoderPool.Put(enc)
+	}()
+
+	return enc.encode(opts, buf, withBuf, fn)
+}
+
+func (e *Encoder) encode(opts Options, buf []byte, withBuf bool, fn func(enc *Encoder) error) ([]byte, int, Ref, error) {
+	// reset encoder
+	e.Reset(nil)
+
+	// count
+	opts.applyEncoder(e)
+	err := fn(e)
+	if err != nil {
+		return nil, 0, Ref{}, err
+	}
+
+	// check error
+	err = e.Error()
+	if err != nil {
+		return nil, 0, Ref{}, err
+	}
+
+	// get length
+	length := e.Length()
+
 	// check length
 	if withBuf && len(buf) < length {
 		return nil, 0, Ref{}, ErrBufferTooShort
+	} else if withBuf {
+		buf = buf[:length]
 	}
 
 	// get buffer
 	var ref Ref
 	if !withBuf {
-		if pool != nil {
-			buf, ref = pool.Borrow(length, false)
-			buf = buf[:enc.len]
+		if opts.Arena != nil {
+			buf = opts.Arena.Get(length, false)
+		} else if opts.Pool != nil {
+			buf, ref = opts.Pool.Borrow(length, false)
+			buf = buf[:e.len]
 		} else {
 			buf = make([]byte, length)
 		}
 	}
 
 	// reset encoder
-	enc.Reset(buf)
+	e.Reset(buf)
+	opts.applyEncoder(e)
 
 	// encode
-	err = fn(enc)
+	err = fn(e)
 	if err != nil {
 		ref.Release()
 		return nil, 0, Ref{}, err
 	}
 
 	// check error
-	err = enc.Error()
+	err = e.Error()
 	if err != nil {
 		ref.Release()
 		return nil, 0, Ref{}, err
@@ -122,11 +348,47 @@ func encode(pool *Pool, buf []byte, withBuf bool, fn func(enc *Encoder) error) (
 	return buf, length, ref, nil
 }
 
+// WriteOverflowError is set by an encoder if an operation in the write pass
+// writes more bytes than have been counted, for example because the encoding
+// function is not deterministic. The offset is the position at which the
+// failed write started and the length is the requested number of bytes. It
+// matches ErrWriteOverflow using errors.Is.
+type WriteOverflowError struct {
+	Offset int
+	Op     string
+	Length int
+}
+
+// Error implements the error interface.
+func (e *WriteOverflowError) Error() string {
+	return fmt.Sprintf("%s: %s at offset %d (length %d)", ErrWriteOverflow, e.Op, e.Offset, e.Length)
+}
+
+// Unwrap returns ErrWriteOverflow.
+func (e *WriteOverflowError) Unwrap() error {
+	return ErrWriteOverflow
+}
+
 // Encoder manages data encoding.
 type Encoder struct {
 	bo  binary.ByteOrder
 	b10 [10]byte
 	len int
+	org []byte
+	gen uint64
+	cmp []block
+	cmi int
+	ber []int
+	bei int
+	dyn *Pool
+	snk *Buffer
+	sbs int
+	fls int
+	ref Ref
+	rcd bool
+	rec []op
+	dat []byte
+	trc func(op string, offset, length int)
 	buf []byte
 	err error
 }
@@ -139,18 +401,67 @@ func NewEncoder() *Encoder {
 }
 
 // Reset will reset the encoder. Pass nil so set the encoder to counting mode.
+// Blocks cached while counting are retained for the following write pass.
 func (e *Encoder) Reset(buf []byte) {
+	// reset or rewind cache and operations
+	if buf == nil {
+		e.cmp = e.cmp[:0]
+		e.ber = e.ber[:0]
+		for i := range e.rec {
+			e.rec[i] = op{}
+		}
+		e.rec = e.rec[:0]
+		e.dat = e.dat[:0]
+	}
+	e.cmi = 0
+	e.bei = 0
+
+	e.rcd = false
+	e.dyn = nil
+	e.snk = nil
+	e.sbs = 0
+	e.fls = 0
+	e.ref = Ref{}
+	e.trc = nil
 	e.bo = binary.BigEndian
 	e.len = 0
+	e.org = buf
+	e.gen++
 	e.buf = buf
 	e.err = nil
 }
 
+// EncodeInto will encode data into the specified byte slice like the package
+// function EncodeInto but using the encoder instead of a pooled one. The
+// encoder is reset before and after encoding.
+func (e *Encoder) EncodeInto(buf []byte, fn func(enc *Encoder) error) (int, error) {
+	// ensure reset
+	defer e.Reset(nil)
+
+	// encode
+	_, n, _, err := e.encode(Options{}, buf, true, fn)
+
+	return n, err
+}
+
 // UseLittleEndian will set the used binary byte order to little endian.
 func (e *Encoder) UseLittleEndian() {
 	e.bo = binary.LittleEndian
 }
 
+// SetByteOrder will set the used binary byte order.
+func (e *Encoder) SetByteOrder(bo binary.ByteOrder) {
+	e.bo = bo
+}
+
+// Trace will set a function that is called for every primitive write with the
+// operation name, the starting offset and the length of the write. The
+// function is only called during the write pass. Composite writes like
+// VarString report their underlying writes. Reset clears the function.
+func (e *Encoder) Trace(fn func(op string, offset, length int)) {
+	e.trc = fn
+}
+
 // Counting returns whether the encoder is counting.
 func (e *Encoder) Counting() bool {
 	return e.buf == nil
@@ -161,11 +472,104 @@ func (e *Encoder) Length() int {
 	return e.len
 }
 
+// Offset returns the number of bytes counted or written since the last reset.
+func (e *Encoder) Offset() int {
+	// handle length
+	if e.buf == nil {
+		return e.len
+	}
+
+	return e.fls + len(e.org) - len(e.buf)
+}
+
 // Error will return the current error.
 func (e *Encoder) Error() error {
 	return e.err
 }
 
+// grow will ensure the buffer can hold the specified amount of bytes when
+// encoding dynamically or into a sink. If the buffer is still too short, a
+// WriteOverflowError is set and false returned.
+func (e *Encoder) grow(op string, num int) bool {
+	return len(e.buf) >= num || e.extend(op, num)
+}
+
+func (e *Encoder) extend(op string, num int) bool {
+	// resize buffer
+	if e.dyn != nil || e.snk != nil {
+		e.resize(num)
+		if len(e.buf) >= num {
+			return true
+		}
+	}
+
+	// set error
+	e.err = &WriteOverflowError{
+		Offset: e.Offset(),
+		Op:     op,
+		Length: num,
+	}
+
+	return false
+}
+
+func (e *Encoder) resize(num int) {
+	// handle buffer
+	if e.snk != nil {
+		// flush staged bytes
+		e.flush()
+		if len(e.buf) >= num {
+			return
+		}
+
+		// borrow larger staging buffer
+		buf, ref := e.snk.pool.Borrow(num, false)
+		e.ref.Release()
+		e.org = buf[:cap(buf)]
+		e.buf = e.org
+		e.ref = ref
+
+		return
+	}
+
+	// determine size
+	off := len(e.org) - len(e.buf)
+	size := len(e.org) * 2
+	if size < off+num {
+		size = off + num
+	}
+
+	// borrow buffer
+	buf, ref := e.dyn.Borrow(size, false)
+	buf = buf[:cap(buf)]
+
+	// copy written bytes
+	copy(buf, e.org[:off])
+
+	// release previous buffer
+	e.ref.Release()
+
+	// set buffer
+	e.org = buf
+	e.buf = buf[off:]
+	e.ref = ref
+}
+
+func (e *Encoder) flush() {
+	// get staged bytes
+	n := len(e.org) - len(e.buf)
+	if n == 0 {
+		return
+	}
+
+	// write staged bytes
+	_, _ = e.snk.WriteAt(e.org[:n], int64(e.sbs+e.fls))
+
+	// reset staging buffer
+	e.fls += n
+	e.buf = e.org
+}
+
 // Skip the specified amount of bytes.
 func (e *Encoder) Skip(num int) {
 	// skip if errored
@@ -173,21 +577,165 @@ func (e *Encoder) Skip(num int) {
 		return
 	}
 
+	// check size
+	if num < 0 {
+		e.err = ErrInvalidSize
+		return
+	}
+
 	// handle length
 	if e.buf == nil {
+		if e.rcd {
+			e.record(op{code: opSkip, size: num})
+		}
 		e.len += num
 		return
 	}
 
+	// grow buffer
+	if !e.grow("skip", num) {
+		return
+	}
+
 	// write zeros
-	for i := 0; i < num; i++ {
-		e.buf[i] = 0
+	buf := e.buf[:num]
+	for i := range buf {
+		buf[i] = 0
+	}
+
+	// trace
+	if e.trc != nil {
+		e.trc("skip", e.Offset(), num)
 	}
 
 	// slice
 	e.buf = e.buf[num:]
 }
 
+// Fill writes the specified amount of copies of the provided byte.
+func (e *Encoder) Fill(num int, b byte) {
+	// skip if errored
+	if e.err != nil {
+		return
+	}
+
+	// check size
+	if num < 0 {
+		e.err = ErrInvalidSize
+		return
+	}
+
+	// handle length
+	if e.buf == nil {
+		if e.rcd {
+			e.record(op{code: opFill, size: num, num: uint64(b)})
+		}
+		e.len += num
+		return
+	}
+
+	// grow buffer
+	if !e.grow("fill", num) {
+		return
+	}
+
+	// write bytes
+	if num > 0 {
+		e.buf[0] = b
+		for i := 1; i < num; i *= 2 {
+			copy(e.buf[i:num], e.buf[:i])
+		}
+	}
+
+	// trace
+	if e.trc != nil {
+		e.trc("fill", e.Offset(), num)
+	}
+
+	// slice
+	e.buf = e.buf[num:]
+}
+
+// Reservation is a handle to a reserved region returned by Reserve.
+type Reservation struct {
+	enc *Encoder
+	gen uint64
+	off int
+	num int
+}
+
+// Reserve will skip the specified amount of bytes and return a handle that can
+// be used to patch the region later during the write pass.
+func (e *Encoder) Reserve(num int) Reservation {
+	// skip if errored
+	if e.err != nil {
+		return Reservation{}
+	}
+
+	// get offset
+	off := e.Offset()
+
+	// skip region
+	e.Skip(num)
+
+	return Reservation{
+		enc: e,
+		gen: e.gen,
+		off: off,
+		num: num,
+	}
+}
+
+// Patch will call the provided function with the reserved region during the
+// write pass. It is a no-op when counting or errored and will panic if the
+// reservation has not been created by the current encoding.
+func (e *Encoder) Patch(res Reservation, fn func(buf []byte)) {
+	// skip if errored
+	if e.err != nil {
+		return
+	}
+
+	// handle counting
+	if e.buf == nil {
+		if e.rcd {
+			e.record(op{code: opFunc, fn: func(enc *Encoder) {
+				fn(enc.org[res.off : res.off+res.num])
+			}})
+		}
+		return
+	}
+
+	// check reservation
+	if res.enc != e || res.gen != e.gen {
+		panic("fpack: invalid reservation")
+	}
+
+	// patch flushed region
+	if e.snk != nil {
+		e.flush()
+		buf := make([]byte, res.num)
+		_, _ = e.snk.ReadAt(buf, int64(e.sbs+res.off))
+		fn(buf)
+		_, _ = e.snk.WriteAt(buf, int64(e.sbs+res.off))
+		return
+	}
+
+	// patch
+	fn(e.org[res.off : res.off+res.num])
+}
+
+// PatchUint32 will write a four byte unsigned integer to the reserved region
+// during the write pass. It will panic if the region is not four bytes long.
+func (e *Encoder) PatchUint32(res Reservation, num uint32) {
+	bo := e.bo
+	e.Patch(res, func(buf []byte) {
+		if len(buf) != 4 {
+			panic("fpack: reservation size mismatch")
+		}
+		bo.PutUint32(buf, num)
+	})
+}
+
 // Bool writes a boolean.
 func (e *Encoder) Bool(yes bool) {
 	if yes {
@@ -197,6 +745,23 @@ func (e *Encoder) Bool(yes bool) {
 	}
 }
 
+// Option writes an optional value. The presence is written as a boolean and
+// the provided function is only called if the value is present.
+func (e *Encoder) Option(present bool, fn func(enc *Encoder)) {
+	// skip if errored
+	if e.err != nil {
+		return
+	}
+
+	// write presence
+	e.Bool(present)
+
+	// encode value
+	if present {
+		fn(e)
+	}
+}
+
 // Int8 writes a one byte signed integer (two's complement).
 func (e *Encoder) Int8(num int8) {
 	e.Int(int64(num), 1)
@@ -217,6 +782,60 @@ func (e *Encoder) Int64(num int64) {
 	e.Int(num, 8)
 }
 
+// Int16LE writes a two byte signed integer (two's complement) in little
+// endian byte order.
+func (e *Encoder) Int16LE(num int16) {
+	bo := e.bo
+	e.bo = binary.LittleEndian
+	e.Int(int64(num), 2)
+	e.bo = bo
+}
+
+// Int16BE writes a two byte signed integer (two's complement) in big
+// endian byte order.
+func (e *Encoder) Int16BE(num int16) {
+	bo := e.bo
+	e.bo = binary.BigEndian
+	e.Int(int64(num), 2)
+	e.bo = bo
+}
+
+// Int32LE writes a four byte signed integer (two's complement) in little
+// endian byte order.
+func (e *Encoder) Int32LE(num int32) {
+	bo := e.bo
+	e.bo = binary.LittleEndian
+	e.Int(int64(num), 4)
+	e.bo = bo
+}
+
+// Int32BE writes a four byte signed integer (two's complement) in big
+// endian byte order.
+func (e *Encoder) Int32BE(num int32) {
+	bo := e.bo
+	e.bo = binary.BigEndian
+	e.Int(int64(num), 4)
+	e.bo = bo
+}
+
+// Int64LE writes an eight byte signed integer (two's complement) in little
+// endian byte order.
+func (e *Encoder) Int64LE(num int64) {
+	bo := e.bo
+	e.bo = binary.LittleEndian
+	e.Int(int64(num), 8)
+	e.bo = bo
+}
+
+// Int64BE writes an eight byte signed integer (two's complement) in big
+// endian byte order.
+func (e *Encoder) Int64BE(num int64) {
+	bo := e.bo
+	e.bo = binary.BigEndian
+	e.Int(int64(num), 8)
+	e.bo = bo
+}
+
 // Int writes a one, two, four or eight byte signed integer (two's complement).
 func (e *Encoder) Int(n int64, size int) {
 	// skip if errored
@@ -248,20 +867,24 @@ func (e *Encoder) Int(n int64, size int) {
 
 	// handle length
 	if e.buf == nil {
+		if e.rcd {
+			e.record(op{code: opInt, size: size, num: un, bo: e.bo})
+		}
 		e.len += size
 		return
 	}
 
+	// grow buffer
+	if !e.grow("int", size) {
+		return
+	}
+
 	// write number
-	switch size {
-	case 1:
-		e.buf[0] = uint8(un)
-	case 2:
-		e.bo.PutUint16(e.buf, uint16(un))
-	case 4:
-		e.bo.PutUint32(e.buf, uint32(un))
-	case 8:
-		e.bo.PutUint64(e.buf, un)
+	putUint(e.bo, e.buf, un, size)
+
+	// trace
+	if e.trc != nil {
+		e.trc("int", e.Offset(), size)
 	}
 
 	// slice
@@ -288,6 +911,54 @@ func (e *Encoder) Uint64(num uint64) {
 	e.Uint(num, 8)
 }
 
+// Uint16LE writes a two byte unsigned integer in little endian byte order.
+func (e *Encoder) Uint16LE(num uint16) {
+	bo := e.bo
+	e.bo = binary.LittleEndian
+	e.Uint(uint64(num), 2)
+	e.bo = bo
+}
+
+// Uint16BE writes a two byte unsigned integer in big endian byte order.
+func (e *Encoder) Uint16BE(num uint16) {
+	bo := e.bo
+	e.bo = binary.BigEndian
+	e.Uint(uint64(num), 2)
+	e.bo = bo
+}
+
+// Uint32LE writes a four byte unsigned integer in little endian byte order.
+func (e *Encoder) Uint32LE(num uint32) {
+	bo := e.bo
+	e.bo = binary.LittleEndian
+	e.Uint(uint64(num), 4)
+	e.bo = bo
+}
+
+// Uint32BE writes a four byte unsigned integer in big endian byte order.
+func (e *Encoder) Uint32BE(num uint32) {
+	bo := e.bo
+	e.bo = binary.BigEndian
+	e.Uint(uint64(num), 4)
+	e.bo = bo
+}
+
+// Uint64LE writes an eight byte unsigned integer in little endian byte order.
+func (e *Encoder) Uint64LE(num uint64) {
+	bo := e.bo
+	e.bo = binary.LittleEndian
+	e.Uint(uint64(num), 8)
+	e.bo = bo
+}
+
+// Uint64BE writes an eight byte unsigned integer in big endian byte order.
+func (e *Encoder) Uint64BE(num uint64) {
+	bo := e.bo
+	e.bo = binary.BigEndian
+	e.Uint(uint64(num), 8)
+	e.bo = bo
+}
+
 // Uint writes a one, two, four or eight byte unsigned integer.
 func (e *Encoder) Uint(num uint64, size int) {
 	// skip if errored
@@ -316,24 +987,100 @@ func (e *Encoder) Uint(num uint64, size int) {
 
 	// handle length
 	if e.buf == nil {
+		if e.rcd {
+			e.record(op{code: opUint, size: size, num: num, bo: e.bo})
+		}
 		e.len += size
 		return
 	}
 
+	// grow buffer
+	if !e.grow("uint", size) {
+		return
+	}
+
 	// write number
+	putUint(e.bo, e.buf, num, size)
+
+	// trace
+	if e.trc != nil {
+		e.trc("uint", e.Offset(), size)
+	}
+
+	// slice
+	e.buf = e.buf[size:]
+}
+
+func putUintAny(bo binary.ByteOrder, buf []byte, num uint64, size int) {
 	switch size {
 	case 1:
-		e.buf[0] = uint8(num)
+		buf[0] = uint8(num)
 	case 2:
-		e.bo.PutUint16(e.buf, uint16(num))
+		bo.PutUint16(buf, uint16(num))
 	case 4:
-		e.bo.PutUint32(e.buf, uint32(num))
+		bo.PutUint32(buf, uint32(num))
 	case 8:
-		e.bo.PutUint64(e.buf, num)
+		bo.PutUint64(buf, num)
+	}
+}
+
+// Enum writes a one, two, four or eight byte unsigned integer that must not
+// exceed the specified maximum value.
+func (e *Encoder) Enum(num uint64, size int, max uint64) {
+	// skip if errored
+	if e.err != nil {
+		return
 	}
 
-	// slice
-	e.buf = e.buf[size:]
+	// check value
+	if num > max {
+		e.err = ErrInvalidEnum
+		return
+	}
+
+	// write number
+	e.Uint(num, size)
+}
+
+// Version writes a one, two, four or eight byte unsigned version number and
+// calls the provided function to encode the version specific data.
+func (e *Encoder) Version(version uint64, size int, fn func(enc *Encoder)) {
+	// skip if errored
+	if e.err != nil {
+		return
+	}
+
+	// write version
+	e.Uint(version, size)
+	if e.err != nil {
+		return
+	}
+
+	// encode body
+	fn(e)
+}
+
+// List writes a variable count prefixed list and calls the provided function
+// once for every item.
+func (e *Encoder) List(num int, fn func(enc *Encoder, i int)) {
+	// skip if errored
+	if e.err != nil {
+		return
+	}
+
+	// check count
+	if num < 0 {
+		e.err = ErrInvalidSize
+		return
+	}
+
+	// write count
+	e.VarUint(uint64(num))
+
+	// encode items
+	for i := 0; i < num && e.err == nil; i++ {
+		fn(e, i)
+	}
 }
 
 // Float32 writes a four byte float.
@@ -346,6 +1093,76 @@ func (e *Encoder) Float64(num float64) {
 	e.Uint64(math.Float64bits(num))
 }
 
+// Float32Checked writes a four byte float and sets ErrNonFiniteFloat if the
+// number is NaN or infinite.
+func (e *Encoder) Float32Checked(num float32) {
+	// skip if errored
+	if e.err != nil {
+		return
+	}
+
+	// check number
+	if math.IsNaN(float64(num)) || math.IsInf(float64(num), 0) {
+		e.err = ErrNonFiniteFloat
+		return
+	}
+
+	e.Float32(num)
+}
+
+// Float64Checked writes an eight byte float and sets ErrNonFiniteFloat if the
+// number is NaN or infinite.
+func (e *Encoder) Float64Checked(num float64) {
+	// skip if errored
+	if e.err != nil {
+		return
+	}
+
+	// check number
+	if math.IsNaN(num) || math.IsInf(num, 0) {
+		e.err = ErrNonFiniteFloat
+		return
+	}
+
+	e.Float64(num)
+}
+
+// FixedQ16 writes a Q16.16 signed fixed-point number as a four byte integer.
+func (e *Encoder) FixedQ16(num float64) {
+	e.Fixed(num, 16, 16)
+}
+
+// Fixed writes a signed fixed-point number with the specified amount of integer
+// and fractional bits. The sum of the bits must be 8, 16, 32 or 64. The number
+// is rounded half away from zero and ErrNumberOverflow is set if it does not
+// fit.
+func (e *Encoder) Fixed(num float64, intBits, fracBits int) {
+	// skip if errored
+	if e.err != nil {
+		return
+	}
+
+	// check bits
+	total := intBits + fracBits
+	if intBits < 0 || fracBits < 0 || (total != 8 && total != 16 && total != 32 && total != 64) {
+		e.err = ErrInvalidSize
+		return
+	}
+
+	// scale and round
+	raw := math.Round(math.Ldexp(num, fracBits))
+
+	// check range (also catches NaN)
+	limit := math.Ldexp(1, total-1)
+	if !(raw >= -limit && raw < limit) {
+		e.err = ErrNumberOverflow
+		return
+	}
+
+	// write number
+	e.Int(int64(raw), total/8)
+}
+
 // VarInt writes a variable signed integer.
 func (e *Encoder) VarInt(num int64) {
 	// skip if errored
@@ -355,12 +1172,31 @@ func (e *Encoder) VarInt(num int64) {
 
 	// handle length
 	if e.buf == nil {
+		if e.rcd {
+			e.record(op{code: opVarInt, num: uint64(num)})
+		}
 		e.len += binary.PutVarint(e.b10[:], num)
 		return
 	}
 
 	// write number
-	n := binary.PutVarint(e.buf, num)
+	var n int
+	if len(e.buf) >= binary.MaxVarintLen64 {
+		n = binary.PutVarint(e.buf, num)
+	} else {
+		n = binary.PutVarint(e.b10[:], num)
+		if !e.grow("varint", n) {
+			return
+		}
+		copy(e.buf, e.b10[:n])
+	}
+
+	// trace
+	if e.trc != nil {
+		e.trc("varint", e.Offset(), n)
+	}
+
+	// slice
 	e.buf = e.buf[n:]
 }
 
@@ -373,15 +1209,41 @@ func (e *Encoder) VarUint(num uint64) {
 
 	// handle length
 	if e.buf == nil {
+		if e.rcd {
+			e.record(op{code: opVarUint, num: num})
+		}
 		e.len += binary.PutUvarint(e.b10[:], num)
 		return
 	}
 
 	// write number
-	n := binary.PutUvarint(e.buf, num)
+	var n int
+	if len(e.buf) >= binary.MaxVarintLen64 {
+		n = binary.PutUvarint(e.buf, num)
+	} else {
+		n = binary.PutUvarint(e.b10[:], num)
+		if !e.grow("varuint", n) {
+			return
+		}
+		copy(e.buf, e.b10[:n])
+	}
+
+	// trace
+	if e.trc != nil {
+		e.trc("varuint", e.Offset(), n)
+	}
+
+	// slice
 	e.buf = e.buf[n:]
 }
 
+// Decimal writes a scaled decimal as a variable signed integer for the units
+// followed by a one byte scale.
+func (e *Encoder) Decimal(units int64, scale uint8) {
+	e.VarInt(units)
+	e.Uint8(scale)
+}
+
 // TimeUnix writes a Unix timestamps in seconds.
 func (e *Encoder) TimeUnix(ts time.Time) {
 	e.Int64(ts.Unix())
@@ -396,12 +1258,27 @@ func (e *Encoder) String(str string) {
 
 	// handle length
 	if e.buf == nil {
+		if e.rcd {
+			e.recordString(str)
+		}
 		e.len += len(str)
 		return
 	}
 
+	// grow buffer
+	if !e.grow("string", len(str)) {
+		return
+	}
+
 	// write string
 	n := copy(e.buf, str)
+
+	// trace
+	if e.trc != nil {
+		e.trc("string", e.Offset(), n)
+	}
+
+	// slice
 	e.buf = e.buf[n:]
 }
 
@@ -414,25 +1291,156 @@ func (e *Encoder) Bytes(buf []byte) {
 
 	// handle length
 	if e.buf == nil {
+		if e.rcd {
+			e.recordBytes(buf)
+		}
 		e.len += len(buf)
 		return
 	}
 
+	// grow buffer
+	if !e.grow("bytes", len(buf)) {
+		return
+	}
+
 	// write bytes
 	n := copy(e.buf, buf)
+
+	// trace
+	if e.trc != nil {
+		e.trc("bytes", e.Offset(), n)
+	}
+
+	// slice
+	e.buf = e.buf[n:]
+}
+
+// CopyN writes exactly n bytes read from the provided reader. The reader is
+// only read during the write pass or while recording, so it is consumed once
+// per encoding. If the reader returns fewer bytes, io.ErrUnexpectedEOF or the
+// reader error is set.
+func (e *Encoder) CopyN(r io.Reader, n int64) {
+	// skip if errored
+	if e.err != nil {
+		return
+	}
+
+	// check length
+	if n < 0 || n > math.MaxInt {
+		e.err = ErrInvalidSize
+		return
+	}
+
+	// handle length
+	if e.buf == nil {
+		if e.rcd {
+			e.render(int(n), func(enc *Encoder) {
+				enc.CopyN(r, n)
+			})
+		}
+		e.len += int(n)
+		return
+	}
+
+	// grow buffer
+	if !e.grow("copyn", int(n)) {
+		return
+	}
+
+	// read bytes
+	_, err := io.ReadFull(r, e.buf[:n])
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		e.err = err
+		return
+	}
+
+	// trace
+	if e.trc != nil {
+		e.trc("copyn", e.Offset(), int(n))
+	}
+
+	// slice
 	e.buf = e.buf[n:]
 }
 
-// FixString writes a fixed length prefixed string.
+// FixString writes a fixed length prefixed string. Invalid prefix sizes result
+// in a SizeError.
 func (e *Encoder) FixString(str string, lenSize int) {
-	e.Uint(uint64(len(str)), lenSize)
-	e.String(str)
+	if e.prefix("fixstring", lenSize) {
+		e.Uint(uint64(len(str)), lenSize)
+		e.String(str)
+	}
 }
 
-// FixBytes writes a fixed length prefixed byte slice.
+// FixBytes writes a fixed length prefixed byte slice. Invalid prefix sizes
+// result in a SizeError.
 func (e *Encoder) FixBytes(buf []byte, lenSize int) {
-	e.Uint(uint64(len(buf)), lenSize)
-	e.Bytes(buf)
+	if e.prefix("fixbytes", lenSize) {
+		e.Uint(uint64(len(buf)), lenSize)
+		e.Bytes(buf)
+	}
+}
+
+func (e *Encoder) prefix(op string, lenSize int) bool {
+	// skip if errored
+	if e.err != nil {
+		return false
+	}
+
+	// check size
+	if !validPrefix(lenSize) {
+		e.err = &SizeError{Op: op, Size: lenSize}
+		return false
+	}
+
+	return true
+}
+
+// HexString writes a byte slice as a fixed length prefixed lowercase hex
+// string. Invalid prefix sizes result in a SizeError.
+func (e *Encoder) HexString(buf []byte, lenSize int) {
+	// check prefix
+	if !e.prefix("hexstring", lenSize) {
+		return
+	}
+
+	// write length
+	e.Uint(uint64(hex.EncodedLen(len(buf))), lenSize)
+
+	// skip if errored
+	if e.err != nil {
+		return
+	}
+
+	// handle length
+	if e.buf == nil {
+		if e.rcd {
+			e.render(hex.EncodedLen(len(buf)), func(enc *Encoder) {
+				hex.Encode(enc.buf, buf)
+			})
+		}
+		e.len += hex.EncodedLen(len(buf))
+		return
+	}
+
+	// grow buffer
+	if !e.grow("hexstring", hex.EncodedLen(len(buf))) {
+		return
+	}
+
+	// write hex
+	n := hex.Encode(e.buf, buf)
+
+	// trace
+	if e.trc != nil {
+		e.trc("hexstring", e.Offset(), n)
+	}
+
+	// slice
+	e.buf = e.buf[n:]
 }
 
 // VarString writes a variable length prefixed string.
@@ -447,13 +1455,32 @@ func (e *Encoder) VarBytes(buf []byte) {
 	e.Bytes(buf)
 }
 
-// DelString writes a suffix delimited string.
+// DelString writes a suffix delimited string. If the delimiter occurs in the
+// string, or overlaps with its end, ErrDelimiterCollision is set.
 func (e *Encoder) DelString(str, delim string) {
 	// skip if errored
 	if e.err != nil {
 		return
 	}
 
+	// check collision
+	if len(delim) > 0 && delimCollision(str, delim) {
+		e.err = ErrDelimiterCollision
+		return
+	}
+
+	// encode
+	e.RawDelString(str, delim)
+}
+
+// RawDelString writes a suffix delimited string like DelString but without
+// checking the string for the delimiter.
+func (e *Encoder) RawDelString(str, delim string) {
+	// skip if errored
+	if e.err != nil {
+		return
+	}
+
 	// check delimiter
 	if len(delim) == 0 {
 		e.err = ErrEmptyDelimiter
@@ -465,13 +1492,32 @@ func (e *Encoder) DelString(str, delim string) {
 	e.String(delim)
 }
 
-// DelBytes writes a suffix delimited byte slice.
+// DelBytes writes a suffix delimited byte slice. If the delimiter occurs in the
+// byte slice, or overlaps with its end, ErrDelimiterCollision is set.
 func (e *Encoder) DelBytes(buf, delim []byte) {
 	// skip if errored
 	if e.err != nil {
 		return
 	}
 
+	// check collision
+	if len(delim) > 0 && delimCollision(toString(buf), toString(delim)) {
+		e.err = ErrDelimiterCollision
+		return
+	}
+
+	// encode
+	e.RawDelBytes(buf, delim)
+}
+
+// RawDelBytes writes a suffix delimited byte slice like DelBytes but without
+// checking the byte slice for the delimiter.
+func (e *Encoder) RawDelBytes(buf, delim []byte) {
+	// skip if errored
+	if e.err != nil {
+		return
+	}
+
 	// check delimiter
 	if len(delim) == 0 {
 		e.err = ErrEmptyDelimiter
@@ -483,6 +1529,29 @@ func (e *Encoder) DelBytes(buf, delim []byte) {
 	e.Bytes(delim)
 }
 
+// delimCollision returns whether the delimiter would be found before the end
+// of the string when the delimiter is appended.
+func delimCollision(str, delim string) bool {
+	// check string
+	if strings.Contains(str, delim) {
+		return true
+	}
+
+	// check overlaps
+	start := len(str) - len(delim) + 1
+	if start < 0 {
+		start = 0
+	}
+	for i := start; i < len(str); i++ {
+		n := len(str) - i
+		if str[i:] == delim[:n] && delim[n:] == delim[:len(delim)-n] {
+			return true
+		}
+	}
+
+	return false
+}
+
 // Tail writes a tail byte slice.
 func (e *Encoder) Tail(buf []byte) {
 	// skip if errored
@@ -492,11 +1561,31 @@ func (e *Encoder) Tail(buf []byte) {
 
 	// handle length
 	if e.buf == nil {
+		if e.rcd {
+			e.recordBytes(buf)
+		}
 		e.len += len(buf)
 		return
 	}
 
+	// grow buffer
+	if !e.grow("tail", len(buf)) {
+		return
+	}
+
 	// write bytes
 	n := copy(e.buf, buf)
+
+	// trace
+	if e.trc != nil {
+		e.trc("tail", e.Offset(), n)
+	}
+
+	// slice
 	e.buf = e.buf[n:]
 }
+
+// TailString writes a tail string.
+func (e *Encoder) TailString(str string) {
+	e.String(str)
+}
@@ -1,8 +1,13 @@
 package fpack
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
 	"io"
 	"math"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -310,6 +315,43 @@ func TestEncodeInvalidSize(t *testing.T) {
 	assert.Empty(t, data)
 	assert.Zero(t, ref)
 	assert.Equal(t, ErrInvalidSize, err)
+
+	data, ref, err = Encode(nil, func(enc *Encoder) error {
+		enc.Skip(-5)
+		return nil
+	})
+	assert.Error(t, err)
+	assert.Empty(t, data)
+	assert.Zero(t, ref)
+	assert.Equal(t, ErrInvalidSize, err)
+}
+
+func TestEncodeInvalidPrefix(t *testing.T) {
+	table := []func(*Encoder){
+		func(enc *Encoder) {
+			enc.FixString("foo", 3)
+		},
+		func(enc *Encoder) {
+			enc.FixBytes([]byte("foo"), 3)
+		},
+		func(enc *Encoder) {
+			enc.HexString([]byte("foo"), 3)
+		},
+		func(enc *Encoder) {
+			enc.UTF16String("foo", 3)
+		},
+	}
+
+	for _, item := range table {
+		_, _, err := Encode(nil, func(enc *Encoder) error {
+			item(enc)
+			return nil
+		})
+		assert.True(t, errors.Is(err, ErrInvalidSize))
+		var se *SizeError
+		assert.True(t, errors.As(err, &se))
+		assert.Equal(t, 3, se.Size)
+	}
 }
 
 func TestEncodeEmptyDelimiter(t *testing.T) {
@@ -332,6 +374,67 @@ func TestEncodeEmptyDelimiter(t *testing.T) {
 	assert.Equal(t, ErrEmptyDelimiter, err)
 }
 
+func TestEncodeDelimiterCollision(t *testing.T) {
+	table := []struct {
+		str   string
+		delim string
+		err   bool
+	}{
+		{str: "foo", delim: "\x00"},
+		{str: "", delim: "\x00"},
+		{str: "foo\x00bar", delim: "\x00", err: true},
+		{str: "foo\x00", delim: "\x00", err: true},
+		{str: "foo\r", delim: "\r\n"},
+		{str: "foo\r\nbar", delim: "\r\n", err: true},
+		{str: "foo\x01", delim: "\x01\x01", err: true},
+		{str: "foo\x01\x02", delim: "\x01\x02\x01", err: true},
+		{str: "foo\x01", delim: "\x01\x02\x01"},
+	}
+
+	for _, item := range table {
+		var calls int
+		buf, _, err := Encode(nil, func(enc *Encoder) error {
+			calls++
+			enc.DelString(item.str, item.delim)
+			return nil
+		})
+		if item.err {
+			assert.Equal(t, ErrDelimiterCollision, err, item.str)
+			assert.Equal(t, 1, calls)
+		} else {
+			assert.NoError(t, err, item.str)
+			assert.Equal(t, item.str+item.delim, string(buf))
+		}
+
+		_, _, err = Encode(nil, func(enc *Encoder) error {
+			enc.DelBytes([]byte(item.str), []byte(item.delim))
+			return nil
+		})
+		if item.err {
+			assert.Equal(t, ErrDelimiterCollision, err, item.str)
+		} else {
+			assert.NoError(t, err, item.str)
+		}
+
+		var str string
+		err = Decode([]byte(item.str+item.delim), func(dec *Decoder) error {
+			str = dec.DelString(item.delim, false)
+			dec.Tail(false)
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, !item.err, str == item.str, item.str)
+	}
+
+	buf, _, err := Encode(nil, func(enc *Encoder) error {
+		enc.RawDelString("foo\x00bar", "\x00")
+		enc.RawDelBytes([]byte("foo\x00bar"), []byte("\x00"))
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "foo\x00bar\x00foo\x00bar\x00", string(buf))
+}
+
 func TestEncodeAllocation(t *testing.T) {
 	withAndWithoutPool(func(pool *Pool) {
 		allocs := 0.0
@@ -365,6 +468,297 @@ func TestEncodeInto(t *testing.T) {
 	})
 	assert.NoError(t, err)
 	assert.Equal(t, 1, n)
+
+	n, err = EncodeInto(make([]byte, 10), func(enc *Encoder) error {
+		enc.Uint8(1)
+		enc.Uint(256, 1)
+		return nil
+	})
+	assert.Equal(t, ErrNumberOverflow, err)
+	assert.Zero(t, n)
+}
+
+func TestMustEncodeInto(t *testing.T) {
+	n, ok := MustEncodeInto(nil, func(enc *Encoder) {
+		enc.VarInt(42)
+	})
+	assert.False(t, ok)
+	assert.Zero(t, n)
+
+	n, ok = MustEncodeInto(make([]byte, 10), func(enc *Encoder) {
+		enc.VarUint(42)
+	})
+	assert.True(t, ok)
+	assert.Equal(t, 1, n)
+
+	assert.PanicsWithValue(t, ErrNumberOverflow, func() {
+		MustEncodeInto(make([]byte, 10), func(enc *Encoder) {
+			enc.Uint8(1)
+			enc.Uint(256, 1)
+		})
+	})
+
+	assert.PanicsWithValue(t, ErrInvalidSize, func() {
+		MustEncodeInto(make([]byte, 10), func(enc *Encoder) {
+			enc.Uint(1, 3)
+		})
+	})
+}
+
+func TestEncodeWriteOverflow(t *testing.T) {
+	table := []func(*Encoder){
+		func(enc *Encoder) {
+			enc.Skip(1)
+		},
+		func(enc *Encoder) {
+			enc.Fill(1, ' ')
+		},
+		func(enc *Encoder) {
+			enc.Int(1, 8)
+		},
+		func(enc *Encoder) {
+			enc.Uint(1, 8)
+		},
+		func(enc *Encoder) {
+			enc.VarInt(1)
+		},
+		func(enc *Encoder) {
+			enc.VarUint(1)
+		},
+		func(enc *Encoder) {
+			enc.String("foo")
+		},
+		func(enc *Encoder) {
+			enc.Bytes([]byte("foo"))
+		},
+		func(enc *Encoder) {
+			enc.CopyN(strings.NewReader("foo"), 3)
+		},
+		func(enc *Encoder) {
+			enc.HexString([]byte("foo"), 1)
+		},
+		func(enc *Encoder) {
+			enc.Tail([]byte("foo"))
+		},
+		func(enc *Encoder) {
+			enc.BERLength(300)
+		},
+		func(enc *Encoder) {
+			enc.GroupUint32(1, 2, 3, 4)
+		},
+		func(enc *Encoder) {
+			enc.PackedASCII("foo")
+		},
+		func(enc *Encoder) {
+			enc.UTF16String("foo", 1)
+		},
+	}
+
+	for _, item := range table {
+		var calls int
+		_, _, err := Encode(nil, func(enc *Encoder) error {
+			calls++
+			enc.Uint8(1)
+			if calls > 1 {
+				item(enc)
+				enc.Uint8(2)
+			}
+			return nil
+		})
+		assert.True(t, errors.Is(err, ErrWriteOverflow))
+		var woe *WriteOverflowError
+		assert.True(t, errors.As(err, &woe))
+		assert.Equal(t, 1, woe.Offset)
+	}
+
+	buf := make([]byte, 16)
+	var calls int
+	_, err := EncodeInto(buf, func(enc *Encoder) error {
+		calls++
+		enc.Uint8(1)
+		if calls > 1 {
+			enc.Uint16(2)
+		}
+		return nil
+	})
+	assert.Equal(t, &WriteOverflowError{Offset: 1, Op: "uint", Length: 2}, err)
+	assert.Equal(t, "write overflow: uint at offset 1 (length 2)", err.Error())
+}
+
+func TestEncoderEncodeInto(t *testing.T) {
+	enc := NewEncoder()
+	enc.UseLittleEndian()
+
+	buf := make([]byte, 10)
+	n, err := enc.EncodeInto(buf, func(enc *Encoder) error {
+		enc.Uint16(1)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, []byte{0, 1}, buf[:n])
+
+	n, err = enc.EncodeInto(buf[:1], func(enc *Encoder) error {
+		enc.Uint16(1)
+		return nil
+	})
+	assert.Equal(t, ErrBufferTooShort, err)
+	assert.Zero(t, n)
+
+	n, err = enc.EncodeInto(buf, func(enc *Encoder) error {
+		enc.Uint(256, 1)
+		return nil
+	})
+	assert.Equal(t, ErrNumberOverflow, err)
+	assert.Zero(t, n)
+	assert.NoError(t, enc.Error())
+
+	assert.Equal(t, 0.0, testing.AllocsPerRun(10, func() {
+		_, _ = enc.EncodeInto(buf, func(enc *Encoder) error {
+			enc.Uint32(42)
+			return nil
+		})
+	}))
+}
+
+func TestEncodeArena(t *testing.T) {
+	arena := NewArena(Global(), 64)
+
+	buf1, err := EncodeArena(arena, func(enc *Encoder) error {
+		enc.String("foo")
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("foo"), buf1)
+	assert.Equal(t, 3, arena.Length())
+
+	buf2, err := EncodeArena(arena, func(enc *Encoder) error {
+		encodeDummy(enc)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, dummy, buf2)
+	assert.Equal(t, 3+len(dummy), arena.Length())
+	assert.Len(t, arena.refs, 2)
+
+	buf3, err := EncodeArena(arena, func(enc *Encoder) error {
+		return io.EOF
+	})
+	assert.Equal(t, io.EOF, err)
+	assert.Nil(t, buf3)
+
+	arena.Release()
+}
+
+func TestEncodeBuffer(t *testing.T) {
+	buf := NewBuffer(Global(), 16)
+	_, _ = buf.Write([]byte("foo"))
+
+	n, err := EncodeBuffer(buf, func(enc *Encoder) error {
+		encodeDummy(enc)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, len(dummy), n)
+	assert.Equal(t, 3+len(dummy), buf.Length())
+
+	data := make([]byte, len(dummy))
+	_, err = buf.ReadAt(data, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, dummy, data)
+
+	fn := func(enc *Encoder) error {
+		res := enc.Reserve(4)
+		enc.String(strings.Repeat("x", 40))
+		enc.Uint64(42)
+		enc.PatchUint32(res, uint32(enc.Offset()))
+		enc.HMAC(sha256.New, []byte("secret"))
+		enc.Uint8(1)
+		return nil
+	}
+
+	exp, _, err := Encode(nil, fn)
+	assert.NoError(t, err)
+
+	n, err = EncodeBuffer(buf, fn)
+	assert.NoError(t, err)
+	assert.Equal(t, len(exp), n)
+
+	data = make([]byte, len(exp))
+	_, err = buf.ReadAt(data, int64(3+len(dummy)))
+	assert.NoError(t, err)
+	assert.Equal(t, exp, data)
+
+	off, err := buf.Seek(0, io.SeekCurrent)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(buf.Length()), off)
+
+	n, err = EncodeBuffer(buf, func(enc *Encoder) error {
+		return io.EOF
+	})
+	assert.Equal(t, io.EOF, err)
+	assert.Zero(t, n)
+
+	buf.Release()
+}
+
+func TestEncodeBufferDescending(t *testing.T) {
+	buf := NewBuffer(Global(), 16)
+
+	fn := func(enc *Encoder) error {
+		enc.String(strings.Repeat("x", 900))
+		enc.DescOrderedString(strings.Repeat("a\x00b", 700))
+		enc.DescOrderedString("abc")
+		return nil
+	}
+
+	exp, _, err := Encode(nil, fn)
+	assert.NoError(t, err)
+
+	n, err := EncodeBuffer(buf, fn)
+	assert.NoError(t, err)
+	assert.Equal(t, len(exp), n)
+
+	data := make([]byte, len(exp))
+	_, err = buf.ReadAt(data, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, exp, data)
+
+	buf.Release()
+}
+
+func TestEncodeAppend(t *testing.T) {
+	buf, err := EncodeAppend([]byte("foo"), func(enc *Encoder) error {
+		enc.Uint16(42)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("foo\x00\x2A"), buf)
+
+	buf, err = EncodeAppend(buf, func(enc *Encoder) error {
+		enc.Uint8(1)
+		return io.EOF
+	})
+	assert.Equal(t, io.EOF, err)
+	assert.Equal(t, []byte("foo\x00\x2A"), buf)
+
+	buf, err = EncodeAppend(buf, func(enc *Encoder) error {
+		enc.Uint(1, 3)
+		return nil
+	})
+	assert.Equal(t, ErrInvalidSize, err)
+	assert.Equal(t, []byte("foo\x00\x2A"), buf)
+
+	buf = make([]byte, 0, 64)
+	allocs := testing.AllocsPerRun(100, func() {
+		buf, _ = EncodeAppend(buf[:0], func(enc *Encoder) error {
+			enc.Uint64(42)
+			enc.String("foo")
+			return nil
+		})
+	})
+	assert.Zero(t, allocs)
+	assert.Len(t, buf, 11)
 }
 
 func TestEncodeByteOrder(t *testing.T) {
@@ -384,6 +778,52 @@ func TestEncodeByteOrder(t *testing.T) {
 	assert.Equal(t, "*\x00", string(buf))
 }
 
+func TestEncodeSetByteOrder(t *testing.T) {
+	buf, _, err := Encode(nil, func(enc *Encoder) error {
+		enc.SetByteOrder(binary.LittleEndian)
+		enc.Uint16(42)
+		enc.SetByteOrder(binary.BigEndian)
+		enc.Uint16(42)
+		enc.SetByteOrder(NativeEndian)
+		enc.Uint16(42)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "*\x00\x00*", string(buf[:4]))
+
+	var native [2]byte
+	NativeEndian.PutUint16(native[:], 42)
+	assert.Equal(t, native[:], buf[4:])
+}
+
+func TestEncodeExplicitByteOrder(t *testing.T) {
+	buf, _, err := Encode(nil, func(enc *Encoder) error {
+		enc.Uint16LE(1)
+		enc.Uint16BE(1)
+		enc.Uint32LE(1)
+		enc.Uint32BE(1)
+		enc.Uint64LE(1)
+		enc.Uint64BE(1)
+		enc.UseLittleEndian()
+		enc.Int16LE(-2)
+		enc.Int16BE(-2)
+		enc.Int32LE(-2)
+		enc.Int32BE(-2)
+		enc.Int64LE(-2)
+		enc.Int64BE(-2)
+		enc.Uint16(1)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "\x01\x00\x00\x01"+
+		"\x01\x00\x00\x00\x00\x00\x00\x01"+
+		"\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x01"+
+		"\xFE\xFF\xFF\xFE"+
+		"\xFE\xFF\xFF\xFF\xFF\xFF\xFF\xFE"+
+		"\xFE\xFF\xFF\xFF\xFF\xFF\xFF\xFF\xFF\xFF\xFF\xFF\xFF\xFF\xFF\xFE"+
+		"\x01\x00", string(buf))
+}
+
 func TestEncodeByteOrderNegative(t *testing.T) {
 	buf, _, err := Encode(nil, func(enc *Encoder) error {
 		enc.Int16(42)
@@ -416,6 +856,378 @@ func TestEncodeByteOrderNegative(t *testing.T) {
 	assert.Equal(t, "\xD6\xFF", string(buf))
 }
 
+func TestEncodeOption(t *testing.T) {
+	buf, _, err := Encode(nil, func(enc *Encoder) error {
+		enc.Option(true, func(enc *Encoder) {
+			enc.Uint8(42)
+		})
+		enc.Option(false, func(enc *Encoder) {
+			enc.Uint8(42)
+		})
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{1, 42, 0}, buf)
+}
+
+func TestEncodeEnum(t *testing.T) {
+	buf, _, err := Encode(nil, func(enc *Encoder) error {
+		enc.Enum(3, 1, 3)
+		enc.Enum(0, 2, 3)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{3, 0, 0}, buf)
+
+	buf, _, err = Encode(nil, func(enc *Encoder) error {
+		enc.Enum(4, 1, 3)
+		return nil
+	})
+	assert.Equal(t, ErrInvalidEnum, err)
+	assert.Empty(t, buf)
+}
+
+func TestEncodeDecimal(t *testing.T) {
+	buf, _, err := Encode(nil, func(enc *Encoder) error {
+		enc.Decimal(-12345, 2)
+		enc.Decimal(7, 0)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0xF1, 0xC0, 0x01, 0x02, 0x0E, 0x00}, buf)
+}
+
+func TestEncodeVersion(t *testing.T) {
+	buf, _, err := Encode(nil, func(enc *Encoder) error {
+		enc.Version(2, 1, func(enc *Encoder) {
+			enc.Uint16(42)
+		})
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{2, 0, 42}, buf)
+}
+
+func TestEncodeHexString(t *testing.T) {
+	buf, _, err := Encode(nil, func(enc *Encoder) error {
+		enc.HexString([]byte{0xDE, 0xAD, 0xBE, 0xEF}, 1)
+		enc.HexString(nil, 2)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "\x08deadbeef\x00\x00", string(buf))
+
+	_, _, err = Encode(nil, func(enc *Encoder) error {
+		enc.HexString(make([]byte, 128), 1)
+		return nil
+	})
+	assert.Equal(t, ErrNumberOverflow, err)
+}
+
+func TestEncodeTailString(t *testing.T) {
+	buf, _, err := Encode(nil, func(enc *Encoder) error {
+		enc.Uint8(42)
+		enc.TailString("foo")
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "*foo", string(buf))
+}
+
+func TestEncodeList(t *testing.T) {
+	list := []uint16{1, 2, 3}
+
+	buf, _, err := Encode(nil, func(enc *Encoder) error {
+		enc.List(len(list), func(enc *Encoder, i int) {
+			enc.Uint16(list[i])
+		})
+		enc.List(0, func(enc *Encoder, i int) {
+			panic("unexpected")
+		})
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{3, 0, 1, 0, 2, 0, 3, 0}, buf)
+
+	_, _, err = Encode(nil, func(enc *Encoder) error {
+		enc.List(-1, nil)
+		return nil
+	})
+	assert.Equal(t, ErrInvalidSize, err)
+}
+
+func TestEncodeOffset(t *testing.T) {
+	var offsets []int
+	buf, _, err := Encode(nil, func(enc *Encoder) error {
+		offsets = append(offsets, enc.Offset())
+		enc.Uint16(1)
+		offsets = append(offsets, enc.Offset())
+		enc.VarString("foo")
+		offsets = append(offsets, enc.Offset())
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Len(t, buf, 6)
+	assert.Equal(t, []int{0, 2, 6, 0, 2, 6}, offsets)
+
+	n, err := EncodeInto(make([]byte, 16), func(enc *Encoder) error {
+		enc.Uint32(1)
+		if !enc.Counting() {
+			assert.Equal(t, 4, enc.Offset())
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 4, n)
+}
+
+func TestEncodeReserve(t *testing.T) {
+	buf, _, err := Encode(nil, func(enc *Encoder) error {
+		res1 := enc.Reserve(4)
+		res2 := enc.Reserve(1)
+		enc.String("foo")
+		enc.PatchUint32(res1, uint32(enc.Offset()))
+		enc.Patch(res2, func(buf []byte) {
+			buf[0] = 42
+		})
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0, 0, 0, 8, 42, 'f', 'o', 'o'}, buf)
+
+	var res Reservation
+	_, _, err = Encode(nil, func(enc *Encoder) error {
+		res = enc.Reserve(4)
+		return nil
+	})
+	assert.NoError(t, err)
+
+	assert.PanicsWithValue(t, "fpack: invalid reservation", func() {
+		_, _, _ = Encode(nil, func(enc *Encoder) error {
+			enc.Reserve(4)
+			enc.PatchUint32(res, 1)
+			return nil
+		})
+	})
+
+	assert.PanicsWithValue(t, "fpack: reservation size mismatch", func() {
+		_, _, _ = Encode(nil, func(enc *Encoder) error {
+			enc.PatchUint32(enc.Reserve(2), 1)
+			return nil
+		})
+	})
+}
+
+func TestEncodeCopyN(t *testing.T) {
+	buf, _, err := Encode(nil, func(enc *Encoder) error {
+		enc.Uint8(3)
+		enc.CopyN(strings.NewReader("foobar"), 3)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "\x03foo", string(buf))
+
+	_, _, err = Encode(nil, func(enc *Encoder) error {
+		enc.CopyN(strings.NewReader("fo"), 3)
+		return nil
+	})
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+
+	_, _, err = Encode(nil, func(enc *Encoder) error {
+		enc.CopyN(strings.NewReader(""), 3)
+		return nil
+	})
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+
+	_, _, err = Encode(nil, func(enc *Encoder) error {
+		enc.CopyN(strings.NewReader(""), -1)
+		return nil
+	})
+	assert.Equal(t, ErrInvalidSize, err)
+}
+
+func TestEncodeFill(t *testing.T) {
+	withAndWithoutPool(func(pool *Pool) {
+		buf, ref, err := Encode(pool, func(enc *Encoder) error {
+			enc.Fill(0, ' ')
+			enc.String("foo")
+			enc.Fill(7, ' ')
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "foo       ", string(buf))
+		ref.Release()
+	})
+
+	buf, _, err := EncodeDynamic(nil, func(enc *Encoder) error {
+		enc.Fill(100, 'x')
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, strings.Repeat("x", 100), string(buf))
+
+	_, _, err = Encode(nil, func(enc *Encoder) error {
+		enc.Fill(-1, ' ')
+		return nil
+	})
+	assert.Equal(t, ErrInvalidSize, err)
+}
+
+func TestEncodeFixed(t *testing.T) {
+	buf, _, err := Encode(nil, func(enc *Encoder) error {
+		enc.FixedQ16(1.5)
+		enc.FixedQ16(-1.5)
+		enc.FixedQ16(1.0 / (1 << 17))
+		enc.FixedQ16(-1.0 / (1 << 17))
+		enc.Fixed(0.25, 8, 24)
+		enc.UseLittleEndian()
+		enc.FixedQ16(1)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{
+		0x00, 0x01, 0x80, 0x00,
+		0xFF, 0xFE, 0x80, 0x00,
+		0x00, 0x00, 0x00, 0x01,
+		0xFF, 0xFF, 0xFF, 0xFF,
+		0x00, 0x40, 0x00, 0x00,
+		0x00, 0x00, 0x01, 0x00,
+	}, buf)
+
+	for _, num := range []float64{32768, -32768.00001, math.NaN(), math.Inf(1)} {
+		_, _, err = Encode(nil, func(enc *Encoder) error {
+			enc.FixedQ16(num)
+			return nil
+		})
+		assert.Equal(t, ErrNumberOverflow, err, num)
+	}
+
+	_, _, err = Encode(nil, func(enc *Encoder) error {
+		enc.Fixed(1, 8, 16)
+		return nil
+	})
+	assert.Equal(t, ErrInvalidSize, err)
+}
+
+func TestEncodeFloatChecked(t *testing.T) {
+	buf, _, err := Encode(nil, func(enc *Encoder) error {
+		enc.Float32Checked(1.5)
+		enc.Float64Checked(-2.5)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{
+		0x3F, 0xC0, 0, 0,
+		0xC0, 0x04, 0, 0, 0, 0, 0, 0,
+	}, buf)
+
+	for _, num := range []float64{math.NaN(), math.Inf(1), math.Inf(-1)} {
+		_, _, err = Encode(nil, func(enc *Encoder) error {
+			enc.Float32Checked(float32(num))
+			return nil
+		})
+		assert.Equal(t, ErrNonFiniteFloat, err)
+
+		_, _, err = Encode(nil, func(enc *Encoder) error {
+			enc.Float64Checked(num)
+			return nil
+		})
+		assert.Equal(t, ErrNonFiniteFloat, err)
+	}
+}
+
+func TestEncodeDynamic(t *testing.T) {
+	withAndWithoutPool(func(pool *Pool) {
+		var calls int
+		buf, ref, err := EncodeDynamic(pool, func(enc *Encoder) error {
+			calls++
+			encodeDummy(enc)
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, calls)
+		assert.Equal(t, dummy, buf)
+		ref.Release()
+
+		large := bytes.Repeat([]byte("x"), 100_000)
+		buf, ref, err = EncodeDynamic(pool, func(enc *Encoder) error {
+			res := enc.Reserve(4)
+			for i := 0; i < 10; i++ {
+				enc.Bytes(large)
+			}
+			enc.PatchUint32(res, uint32(enc.Offset()))
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Len(t, buf, 1_000_004)
+		assert.Equal(t, []byte{0, 0x0F, 0x42, 0x44}, buf[:4])
+		assert.Equal(t, large, buf[4:100_004])
+		ref.Release()
+
+		buf, ref, err = EncodeDynamic(pool, func(enc *Encoder) error {
+			enc.Bytes(large)
+			return io.EOF
+		})
+		assert.Equal(t, io.EOF, err)
+		assert.Nil(t, buf)
+		assert.Zero(t, ref)
+
+		buf, ref, err = EncodeDynamic(pool, func(enc *Encoder) error {
+			enc.Bytes(large)
+			enc.Uint(1, 3)
+			return nil
+		})
+		assert.Equal(t, ErrInvalidSize, err)
+		assert.Nil(t, buf)
+		assert.Zero(t, ref)
+	})
+}
+
+func TestEncodeSized(t *testing.T) {
+	withAndWithoutPool(func(pool *Pool) {
+		var calls int
+		buf, ref, err := EncodeSized(pool, len(dummy), func(enc *Encoder) error {
+			calls++
+			encodeDummy(enc)
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, calls)
+		assert.Equal(t, dummy, buf)
+		ref.Release()
+
+		buf, ref, err = EncodeSized(pool, len(dummy)+1, func(enc *Encoder) error {
+			encodeDummy(enc)
+			return nil
+		})
+		assert.Equal(t, ErrSizeMismatch, err)
+		assert.Nil(t, buf)
+		assert.Zero(t, ref)
+
+		buf, ref, err = EncodeSized(pool, 4, func(enc *Encoder) error {
+			enc.Bytes(bytes.Repeat([]byte("x"), 10_000))
+			return nil
+		})
+		assert.Equal(t, ErrSizeMismatch, err)
+		assert.Nil(t, buf)
+		assert.Zero(t, ref)
+
+		buf, ref, err = EncodeSized(pool, 4, func(enc *Encoder) error {
+			return io.EOF
+		})
+		assert.Equal(t, io.EOF, err)
+		assert.Nil(t, buf)
+		assert.Zero(t, ref)
+
+		buf, ref, err = EncodeSized(pool, -1, func(enc *Encoder) error {
+			return nil
+		})
+		assert.Equal(t, ErrInvalidSize, err)
+		assert.Nil(t, buf)
+		assert.Zero(t, ref)
+	})
+}
+
 func BenchmarkEncode(b *testing.B) {
 	b.ReportAllocs()
 	b.ResetTimer()
@@ -433,6 +1245,145 @@ func BenchmarkEncode(b *testing.B) {
 	}
 }
 
+func BenchmarkEncodeIntegers(b *testing.B) {
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_, ref, err := Encode(Global(), encodeIntegers)
+		if err != nil {
+			panic(err)
+		}
+
+		ref.Release()
+	}
+}
+
+func BenchmarkEncodeIntegersLE(b *testing.B) {
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_, ref, err := Encode(Global(), func(enc *Encoder) error {
+			enc.UseLittleEndian()
+			return encodeIntegers(enc)
+		})
+		if err != nil {
+			panic(err)
+		}
+
+		ref.Release()
+	}
+}
+
+func BenchmarkEncodeIntegersMixed(b *testing.B) {
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_, ref, err := Encode(Global(), func(enc *Encoder) error {
+			for j := 0; j < 16; j++ {
+				enc.Uint16LE(uint16(j))
+				enc.Uint32LE(uint32(j))
+				enc.Uint64LE(uint64(j))
+				enc.Uint16BE(uint16(j))
+				enc.Uint32BE(uint32(j))
+				enc.Uint64BE(uint64(j))
+				enc.Int64LE(int64(-j))
+			}
+			return nil
+		})
+		if err != nil {
+			panic(err)
+		}
+
+		ref.Release()
+	}
+}
+
+func encodeIntegers(enc *Encoder) error {
+	for j := 0; j < 16; j++ {
+		enc.Uint8(uint8(j))
+		enc.Uint16(uint16(j))
+		enc.Uint32(uint32(j))
+		enc.Uint64(uint64(j))
+		enc.Int16(int16(-j))
+		enc.Int32(int32(-j))
+		enc.Int64(int64(-j))
+	}
+	return nil
+}
+
+func BenchmarkEncodeSkip(b *testing.B) {
+	buf := make([]byte, 1<<16) // 64 KiB
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(buf)))
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_, err := EncodeInto(buf, func(enc *Encoder) error {
+			enc.Skip(len(buf))
+			return nil
+		})
+		if err != nil {
+			panic(err)
+		}
+	}
+}
+
+func BenchmarkEncodeSmall(b *testing.B) {
+	benchmarkEncode(b, Encode, 16)
+}
+
+func BenchmarkEncodeDynamicSmall(b *testing.B) {
+	benchmarkEncode(b, EncodeDynamic, 16)
+}
+
+func BenchmarkEncodeSizedSmall(b *testing.B) {
+	benchmarkEncode(b, encodeSized(16), 16)
+}
+
+func BenchmarkEncodeLarge(b *testing.B) {
+	benchmarkEncode(b, Encode, 10_000)
+}
+
+func BenchmarkEncodeDynamicLarge(b *testing.B) {
+	benchmarkEncode(b, EncodeDynamic, 10_000)
+}
+
+func BenchmarkEncodeSizedLarge(b *testing.B) {
+	benchmarkEncode(b, encodeSized(10_000), 10_000)
+}
+
+func encodeSized(size int) func(*Pool, func(*Encoder) error) ([]byte, Ref, error) {
+	return func(pool *Pool, fn func(*Encoder) error) ([]byte, Ref, error) {
+		return EncodeSized(pool, size*101, fn)
+	}
+}
+
+func benchmarkEncode(b *testing.B, encode func(*Pool, func(*Encoder) error) ([]byte, Ref, error), size int) {
+	data := bytes.Repeat([]byte("x"), 100)
+
+	b.ReportAllocs()
+	b.SetBytes(int64(size * len(data)))
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_, ref, err := encode(Global(), func(enc *Encoder) error {
+			for j := 0; j < size; j++ {
+				enc.VarBytes(data)
+			}
+			return nil
+		})
+		if err != nil {
+			panic(err)
+		}
+
+		ref.Release()
+	}
+}
+
 func withAndWithoutPool(fn func(*Pool)) {
 	fn(nil)
 	fn(Global())
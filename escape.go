@@ -0,0 +1,178 @@
+package fpack
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+)
+
+// ErrInvalidEscape is returned if an escape sequence is empty or malformed.
+var ErrInvalidEscape = errors.New("invalid escape")
+
+// EscapedDelString writes a suffix delimited string where occurrences of the
+// delimiter in the string are replaced by the escape sequence followed by 0x02
+// and occurrences of the escape sequence are replaced by the escape sequence
+// followed by 0x03. The delimiter and escape sequence must not overlap, which
+// means neither may contain the other and no suffix of one may be a prefix of
+// the other. Otherwise, ErrInvalidEscape is set.
+func (e *Encoder) EscapedDelString(str, delim, escape string) {
+	// skip if errored
+	if e.err != nil {
+		return
+	}
+
+	// check delimiter and escape
+	if len(delim) == 0 {
+		e.err = ErrEmptyDelimiter
+		return
+	} else if len(escape) == 0 || overlaps(delim, escape) {
+		e.err = ErrInvalidEscape
+		return
+	}
+
+	// get bytes
+	buf := toBytes(str)
+	del := toBytes(delim)
+	esc := toBytes(escape)
+
+	// write escaped bytes
+	for len(buf) > 0 {
+		if bytes.HasPrefix(buf, esc) {
+			e.Bytes(esc)
+			e.Uint8(0x03)
+			buf = buf[len(esc):]
+		} else if bytes.HasPrefix(buf, del) {
+			e.Bytes(esc)
+			e.Uint8(0x02)
+			buf = buf[len(del):]
+		} else {
+			e.Uint8(buf[0])
+			buf = buf[1:]
+		}
+	}
+
+	// write delimiter
+	e.Bytes(del)
+}
+
+// EscapedDelString reads a suffix delimited string written by the encoders
+// EscapedDelString method. If the string does not contain escape sequences and
+// is not cloned it may change if the source byte slice changes. Otherwise, the
+// string is unescaped into an allocated buffer, using the arena if configured.
+// Malformed escape sequences and escape sequences that overlap the delimiter
+// result in ErrInvalidEscape.
+func (d *Decoder) EscapedDelString(delim, escape string, clone bool) string {
+	// skip if errored
+	if d.err != nil {
+		return ""
+	}
+
+	// check delimiter and escape
+	if len(delim) == 0 {
+		d.fail(ErrEmptyDelimiter, "escapeddelstring", 0)
+		return ""
+	} else if len(escape) == 0 || overlaps(delim, escape) {
+		d.fail(ErrInvalidEscape, "escapeddelstring", 0)
+		return ""
+	}
+
+	// get bytes
+	del := toBytes(delim)
+	esc := toBytes(escape)
+
+	// stitch following slices
+	d.need(d.Length())
+
+	// find end and determine length
+	var end, length int
+	var escaped bool
+	for {
+		// check length
+		if end >= len(d.buf) {
+			d.fail(ErrBufferTooShort, "escapeddelstring", 0)
+			return ""
+		}
+
+		// check escape and delimiter
+		rest := d.buf[end:]
+		if bytes.HasPrefix(rest, esc) {
+			if len(rest) <= len(esc) {
+				d.fail(ErrBufferTooShort, "escapeddelstring", 0)
+				return ""
+			} else if c := rest[len(esc)]; c != 0x02 && c != 0x03 {
+				d.fail(ErrInvalidEscape, "escapeddelstring", 0)
+				return ""
+			}
+			if rest[len(esc)] == 0x02 {
+				length += len(del)
+			} else {
+				length += len(esc)
+			}
+			end += len(esc) + 1
+			escaped = true
+		} else if bytes.HasPrefix(rest, del) {
+			break
+		} else {
+			length++
+			end++
+		}
+	}
+
+	// handle unescaped strings
+	if !escaped {
+		str := d.string("escapeddelstring", end, clone)
+		d.Skip(len(del))
+		return str
+	}
+
+	// allocate buffer
+	var buf []byte
+	if d.arn != nil {
+		buf = d.arn.Get(length, false)
+	} else {
+		buf = make([]byte, length)
+	}
+
+	// unescape
+	var pos int
+	for i := 0; i < end; {
+		if bytes.HasPrefix(d.buf[i:end], esc) {
+			if d.buf[i+len(esc)] == 0x02 {
+				pos += copy(buf[pos:], del)
+			} else {
+				pos += copy(buf[pos:], esc)
+			}
+			i += len(esc) + 1
+		} else {
+			buf[pos] = d.buf[i]
+			pos++
+			i++
+		}
+	}
+
+	// trace
+	if d.trc != nil {
+		d.trc("escapeddelstring", d.Offset(), end+len(del), toString(buf))
+	}
+
+	// slice
+	d.buf = d.buf[end+len(del):]
+
+	return toString(buf)
+}
+
+func overlaps(a, b string) bool {
+	// check containment
+	if strings.Contains(a, b) || strings.Contains(b, a) {
+		return true
+	}
+
+	// check suffixes against prefixes
+	for i := 1; i < len(a) && i < len(b); i++ {
+		if a[len(a)-i:] == b[:i] || b[len(b)-i:] == a[:i] {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,116 @@
+package fpack
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEscapedDelString(t *testing.T) {
+	buf, _, err := Encode(nil, func(enc *Encoder) error {
+		enc.EscapedDelString("foo", "\x00", "\x01")
+		enc.EscapedDelString("a\x00b\x01c", "\x00", "\x01")
+		enc.EscapedDelString("", "\x00", "\x01")
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "foo\x00a\x01\x02b\x01\x03c\x00\x00", string(buf))
+
+	var str1, str2, str3 string
+	err = Decode(buf, func(dec *Decoder) error {
+		str1 = dec.EscapedDelString("\x00", "\x01", false)
+		str2 = dec.EscapedDelString("\x00", "\x01", false)
+		str3 = dec.EscapedDelString("\x00", "\x01", true)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "foo", str1)
+	assert.Equal(t, "a\x00b\x01c", str2)
+	assert.Equal(t, "", str3)
+
+	buf, _, err = Encode(nil, func(enc *Encoder) error {
+		enc.EscapedDelString("a||b\\c", "||", "\\")
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "a\\\x02b\\\x03c||", string(buf))
+
+	arena := NewArena(Global(), 64)
+	defer arena.Release()
+
+	err = Decode(buf, func(dec *Decoder) error {
+		dec.UseArena(arena)
+		str1 = dec.EscapedDelString("||", "\\", false)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "a||b\\c", str1)
+	assert.Equal(t, 6, arena.Length())
+}
+
+func TestEscapedDelStringErrors(t *testing.T) {
+	_, _, err := Encode(nil, func(enc *Encoder) error {
+		enc.EscapedDelString("foo", "", "\x01")
+		return nil
+	})
+	assert.Equal(t, ErrEmptyDelimiter, err)
+
+	_, _, err = Encode(nil, func(enc *Encoder) error {
+		enc.EscapedDelString("foo", "\x00", "")
+		return nil
+	})
+	assert.Equal(t, ErrInvalidEscape, err)
+
+	for _, pair := range [][2]string{
+		{"ab", "ab"},
+		{"ab", "a"},
+		{"ab", "b"},
+		{"a", "ab"},
+		{"abc", "b"},
+		{"ab", "bc"},
+		{"ab", "ca"},
+	} {
+		_, _, err = Encode(nil, func(enc *Encoder) error {
+			enc.EscapedDelString("ab", pair[0], pair[1])
+			return nil
+		})
+		assert.Equal(t, ErrInvalidEscape, err, pair)
+
+		err = Decode([]byte("xab"), func(dec *Decoder) error {
+			dec.EscapedDelString(pair[0], pair[1], false)
+			return nil
+		})
+		assert.ErrorIs(t, err, ErrInvalidEscape, pair)
+	}
+
+	for _, pair := range [][2]string{
+		{"ab", "c"},
+		{"ab", "ac"},
+		{"\x00", "\x01"},
+	} {
+		buf, _, err := Encode(nil, func(enc *Encoder) error {
+			enc.EscapedDelString("ab\x00\x01c", pair[0], pair[1])
+			return nil
+		})
+		assert.NoError(t, err, pair)
+
+		err = Decode(buf, func(dec *Decoder) error {
+			assert.Equal(t, "ab\x00\x01c", dec.EscapedDelString(pair[0], pair[1], false))
+			return nil
+		})
+		assert.NoError(t, err, pair)
+	}
+
+	table := map[string]error{
+		"foo":             ErrBufferTooShort,
+		"foo\x01":         ErrBufferTooShort,
+		"foo\x01\x04\x00": ErrInvalidEscape,
+	}
+	for data, expected := range table {
+		err = Decode([]byte(data), func(dec *Decoder) error {
+			dec.EscapedDelString("\x00", "\x01", false)
+			return nil
+		})
+		assert.ErrorIs(t, err, expected, data)
+	}
+}
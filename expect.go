@@ -0,0 +1,84 @@
+package fpack
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// ErrMismatch is returned if decoded bytes do not match the expected bytes.
+var ErrMismatch = errors.New("mismatch")
+
+// MismatchError is returned if decoded bytes do not match the expected bytes.
+// It matches ErrMismatch using errors.Is.
+type MismatchError struct {
+	Expected []byte
+	Found    []byte
+}
+
+// Error implements the error interface.
+func (e *MismatchError) Error() string {
+	return fmt.Sprintf("%s: expected %x, found %x", ErrMismatch, e.Expected, e.Found)
+}
+
+// Unwrap returns ErrMismatch.
+func (e *MismatchError) Unwrap() error {
+	return ErrMismatch
+}
+
+// Const writes a raw byte slice that is verified with Expect when decoding.
+func (e *Encoder) Const(buf []byte) {
+	e.Bytes(buf)
+}
+
+// Expect reads a raw byte slice and sets a MismatchError if it does not match
+// the provided byte slice.
+func (d *Decoder) Expect(buf []byte) {
+	// skip if errored
+	if d.err != nil {
+		return
+	}
+
+	// check length
+	if !d.need(len(buf)) {
+		d.fail(ErrBufferTooShort, "expect", len(buf))
+		return
+	}
+
+	// compare bytes
+	if !bytes.Equal(d.buf[:len(buf)], buf) {
+		d.fail(&MismatchError{
+			Expected: append([]byte(nil), buf...),
+			Found:    append([]byte(nil), d.buf[:len(buf)]...),
+		}, "expect", len(buf))
+		return
+	}
+
+	// trace
+	if d.trc != nil {
+		d.trc("expect", d.Offset(), len(buf), buf)
+	}
+
+	// slice
+	d.buf = d.buf[len(buf):]
+}
+
+// ExpectString reads a raw string and sets a MismatchError if it does not
+// match the provided string.
+func (d *Decoder) ExpectString(str string) {
+	d.Expect(toBytes(str))
+}
+
+// ExpectUint8 reads a one byte unsigned integer and sets a MismatchError if it
+// does not match the provided number.
+func (d *Decoder) ExpectUint8(num uint8) {
+	d.Expect([]byte{num})
+}
+
+// ExpectUint16 reads a two byte unsigned integer and sets a MismatchError if
+// it does not match the provided number.
+func (d *Decoder) ExpectUint16(num uint16) {
+	var buf [2]byte
+	d.bo.PutUint16(buf[:], num)
+	d.Expect(buf[:])
+}
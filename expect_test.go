@@ -0,0 +1,54 @@
+package fpack
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpect(t *testing.T) {
+	buf, _, err := Encode(nil, func(enc *Encoder) error {
+		enc.Const([]byte("MAGIC"))
+		enc.Uint8(1)
+		enc.Uint16(2)
+		enc.String("foo")
+		return nil
+	})
+	assert.NoError(t, err)
+
+	err = Decode(buf, func(dec *Decoder) error {
+		dec.Expect([]byte("MAGIC"))
+		dec.ExpectUint8(1)
+		dec.ExpectUint16(2)
+		dec.ExpectString("foo")
+		return nil
+	})
+	assert.NoError(t, err)
+
+	err = Decode(buf, func(dec *Decoder) error {
+		dec.ExpectString("MAGIX")
+		return nil
+	})
+	assert.True(t, errors.Is(err, ErrMismatch))
+	assert.Equal(t, &DecodeError{Offset: 0, Op: "expect", Length: 5, Err: &MismatchError{
+		Expected: []byte("MAGIX"),
+		Found:    []byte("MAGIC"),
+	}}, err)
+	assert.Equal(t, "expect at offset 0 (length 5): mismatch: expected 4d41474958, found 4d41474943", err.Error())
+
+	err = Decode(buf, func(dec *Decoder) error {
+		dec.Skip(5)
+		dec.ExpectUint8(1)
+		dec.UseLittleEndian()
+		dec.ExpectUint16(2)
+		return nil
+	})
+	assert.True(t, errors.Is(err, ErrMismatch))
+
+	err = Decode([]byte("MAG"), func(dec *Decoder) error {
+		dec.ExpectString("MAGIC")
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrBufferTooShort)
+}
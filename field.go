@@ -0,0 +1,63 @@
+package fpack
+
+// FieldError is set if encoding or decoding a field failed. The path holds the
+// dot separated names of the enclosing fields.
+type FieldError struct {
+	Path string
+	Err  error
+}
+
+// Error implements the error interface.
+func (e *FieldError) Error() string {
+	return e.Path + ": " + e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+func wrapField(name string, err error) error {
+	// join path if already wrapped
+	if fe, ok := err.(*FieldError); ok {
+		return &FieldError{Path: name + "." + fe.Path, Err: fe.Err}
+	}
+
+	return &FieldError{Path: name, Err: err}
+}
+
+// Field runs the provided function and wraps an error that it sets in a
+// FieldError with the provided name. Errors of nested fields are joined into
+// a dot separated path.
+func (e *Encoder) Field(name string, fn func(enc *Encoder)) {
+	// skip if errored
+	if e.err != nil {
+		return
+	}
+
+	// encode field
+	fn(e)
+
+	// wrap error
+	if e.err != nil {
+		e.err = wrapField(name, e.err)
+	}
+}
+
+// Field runs the provided function and wraps an error that it sets in a
+// FieldError with the provided name. Errors of nested fields are joined into
+// a dot separated path.
+func (d *Decoder) Field(name string, fn func(dec *Decoder)) {
+	// skip if errored
+	if d.err != nil {
+		return
+	}
+
+	// decode field
+	fn(d)
+
+	// wrap error
+	if d.err != nil {
+		d.err = wrapField(name, d.err)
+	}
+}
@@ -0,0 +1,82 @@
+package fpack
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncoderField(t *testing.T) {
+	buf, _, err := Encode(nil, func(enc *Encoder) error {
+		enc.Field("foo", func(enc *Encoder) {
+			enc.Uint8(1)
+		})
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{1}, buf)
+
+	buf, _, err = Encode(nil, func(enc *Encoder) error {
+		enc.Field("foo", func(enc *Encoder) {
+			enc.Field("bar", func(enc *Encoder) {
+				enc.Uint(256, 1)
+			})
+		})
+		enc.Field("baz", func(enc *Encoder) {
+			enc.Uint8(1)
+		})
+		return nil
+	})
+	assert.Equal(t, &FieldError{Path: "foo.bar", Err: ErrNumberOverflow}, err)
+	assert.EqualError(t, err, "foo.bar: number overflow")
+	assert.True(t, errors.Is(err, ErrNumberOverflow))
+	assert.Nil(t, buf)
+}
+
+func TestDecoderField(t *testing.T) {
+	err := Decode([]byte{1, 2}, func(dec *Decoder) error {
+		dec.Field("foo", func(dec *Decoder) {
+			assert.Equal(t, uint8(1), dec.Uint8())
+		})
+		dec.Field("bar", func(dec *Decoder) {
+			dec.Field("baz", func(dec *Decoder) {
+				assert.Equal(t, uint8(2), dec.Uint8())
+			})
+		})
+		return nil
+	})
+	assert.NoError(t, err)
+
+	err = Decode([]byte{1}, func(dec *Decoder) error {
+		dec.Field("foo", func(dec *Decoder) {
+			dec.Uint8()
+		})
+		dec.Field("bar", func(dec *Decoder) {
+			dec.Field("baz", func(dec *Decoder) {
+				dec.Uint16()
+			})
+		})
+		dec.Field("qux", func(dec *Decoder) {
+			dec.Uint8()
+		})
+		return nil
+	})
+	assert.Equal(t, &FieldError{Path: "bar.baz", Err: &DecodeError{Offset: 1, Op: "uint16", Length: 2, Err: ErrBufferTooShort}}, err)
+	assert.EqualError(t, err, "bar.baz: uint16 at offset 1 (length 2): buffer too short")
+	assert.True(t, errors.Is(err, ErrBufferTooShort))
+}
+
+func TestFieldAllocation(t *testing.T) {
+	buf := []byte{1, 2}
+	assert.Equal(t, 0.0, testing.AllocsPerRun(10, func() {
+		_ = Decode(buf, func(dec *Decoder) error {
+			dec.Field("foo", func(dec *Decoder) {
+				dec.Field("bar", func(dec *Decoder) {
+					dec.Uint16()
+				})
+			})
+			return nil
+		})
+	}))
+}
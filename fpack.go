@@ -2,7 +2,23 @@
 // sequences.
 package fpack
 
-import "errors"
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"unsafe"
+)
+
+// NativeEndian is the native byte order of the current platform.
+var NativeEndian = nativeEndian()
+
+func nativeEndian() binary.ByteOrder {
+	num := uint16(1)
+	if *(*byte)(unsafe.Pointer(&num)) == 1 {
+		return binary.LittleEndian
+	}
+	return binary.BigEndian
+}
 
 // ErrBufferTooShort is returned if the provided buffer is too short.
 var ErrBufferTooShort = errors.New("buffer too short")
@@ -16,5 +32,90 @@ var ErrNumberOverflow = errors.New("number overflow")
 // ErrEmptyDelimiter is returned if a provided delimiter is empty.
 var ErrEmptyDelimiter = errors.New("empty delimiter")
 
+// ErrDelimiterCollision is returned if a delimited value contains its
+// delimiter.
+var ErrDelimiterCollision = errors.New("delimiter collision")
+
 // ErrInvalidSize is returned if a provided number size is invalid.
 var ErrInvalidSize = errors.New("invalid size")
+
+// ErrInvalidOption is returned if a decoded option presence byte is invalid.
+var ErrInvalidOption = errors.New("invalid option")
+
+// ErrInvalidEnum is returned if an enum value exceeds its maximum.
+var ErrInvalidEnum = errors.New("invalid enum")
+
+// ErrInvalidScale is returned if a decoded decimal scale exceeds its maximum.
+var ErrInvalidScale = errors.New("invalid scale")
+
+// ErrInvalidBool is returned if a decoded boolean is neither 0 nor 1.
+var ErrInvalidBool = errors.New("invalid bool")
+
+// ErrUnknownVersion is returned if no handler matches a decoded version.
+var ErrUnknownVersion = errors.New("unknown version")
+
+// ErrInvalidHex is returned if decoded hex data is malformed.
+var ErrInvalidHex = errors.New("invalid hex")
+
+// ErrListTooLong is returned if a decoded list count exceeds its maximum.
+var ErrListTooLong = errors.New("list too long")
+
+// ErrInvalidLength is returned if a provided maximum length is negative.
+var ErrInvalidLength = errors.New("invalid length")
+
+// ErrNoProgress is returned if a decoding iteration did not consume any bytes.
+var ErrNoProgress = errors.New("no progress")
+
+// ErrInvalidCheckpoint is returned if a checkpoint does not belong to the
+// current decoding.
+var ErrInvalidCheckpoint = errors.New("invalid checkpoint")
+
+// ErrLengthLimit is returned if a decoded length exceeds its limit.
+var ErrLengthLimit = errors.New("length limit exceeded")
+
+// ErrNonFiniteFloat is returned if a checked float is NaN or infinite.
+var ErrNonFiniteFloat = errors.New("non-finite float")
+
+// ErrSizeMismatch is returned if the encoded data does not match the declared
+// size.
+var ErrSizeMismatch = errors.New("size mismatch")
+
+// ErrInvalidUTF8 is returned if a decoded string is not valid UTF-8.
+var ErrInvalidUTF8 = errors.New("invalid utf8")
+
+// ErrInvalidUnread is returned if a byte cannot be unread.
+var ErrInvalidUnread = errors.New("invalid unread")
+
+// ErrInvalidPadding is returned if a padding byte does not match.
+var ErrInvalidPadding = errors.New("invalid padding")
+
+// ErrWriteOverflow is returned if the write pass exceeds the counted length.
+var ErrWriteOverflow = errors.New("write overflow")
+
+// ErrVarintOverflow is returned if a variable integer overflows 64 bits.
+var ErrVarintOverflow = errors.New("varint overflow")
+
+// ErrGenerationMismatch is returned if a reference is closed after it has been
+// released and a misuse function is registered.
+var ErrGenerationMismatch = errors.New("generation mismatch")
+
+// SizeError is set if an invalid length prefix size is provided. It matches
+// ErrInvalidSize using errors.Is.
+type SizeError struct {
+	Op   string
+	Size int
+}
+
+// Error implements the error interface.
+func (e *SizeError) Error() string {
+	return fmt.Sprintf("%s: %s length prefix size %d", ErrInvalidSize, e.Op, e.Size)
+}
+
+// Unwrap returns ErrInvalidSize.
+func (e *SizeError) Unwrap() error {
+	return ErrInvalidSize
+}
+
+func validPrefix(size int) bool {
+	return size == 1 || size == 2 || size == 4 || size == 8
+}
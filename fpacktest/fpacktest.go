@@ -0,0 +1,99 @@
+// Package fpacktest provides helpers to test encoding and decoding functions.
+package fpacktest
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/256dpi/fpack"
+)
+
+// RoundTrip will encode and decode each sample using the provided functions
+// and report an error if the decoded value differs from the sample. It also
+// verifies that the measured length matches the length of the emitted data
+// and that the regular and dynamic encoding produce the same bytes.
+func RoundTrip[T any](t testing.TB, encode func(enc *fpack.Encoder, v T), decode func(dec *fpack.Decoder) T, samples ...T) {
+	t.Helper()
+
+	for i, sample := range samples {
+		// prepare function
+		fn := func(enc *fpack.Encoder) error {
+			encode(enc, sample)
+			return nil
+		}
+
+		// measure
+		length, err := fpack.Measure(fn)
+		if err != nil {
+			t.Errorf("sample %d: measure: %s", i, err)
+			continue
+		}
+
+		// encode
+		buf, _, err := fpack.Encode(nil, fn)
+		if err != nil {
+			t.Errorf("sample %d: encode: %s", i, err)
+			continue
+		}
+
+		// encode dynamically
+		dyn, ref, err := fpack.EncodeDynamic(nil, fn)
+		if err != nil {
+			t.Errorf("sample %d: encode: %s", i, err)
+			continue
+		}
+		emitted := len(dyn)
+		same := bytes.Equal(buf, dyn)
+		ref.Release()
+
+		// check length
+		if emitted != length {
+			t.Errorf("sample %d: measured %d bytes but emitted %d bytes", i, length, emitted)
+			continue
+		} else if !same {
+			t.Errorf("sample %d: encoding is not deterministic", i)
+			continue
+		}
+
+		// decode
+		var out T
+		err = fpack.Decode(buf, func(dec *fpack.Decoder) error {
+			out = decode(dec)
+			return nil
+		})
+		if err != nil {
+			t.Errorf("sample %d: decode: %s", i, err)
+			continue
+		}
+
+		// compare
+		if !reflect.DeepEqual(sample, out) {
+			t.Errorf("sample %d: decoded %#v, expected %#v", i, out, sample)
+		}
+	}
+}
+
+// FuzzDecode will run the provided decoding function with the fuzzing input.
+// Panics are reported as errors together with the input and the number of
+// consumed bytes is verified to not exceed the input. The function may return
+// errors for invalid input.
+func FuzzDecode(f *testing.F, decode func(dec *fpack.Decoder) error) {
+	f.Fuzz(func(t *testing.T, data []byte) {
+		// limit capacity
+		data = data[:len(data):len(data)]
+
+		// recover panics
+		defer func() {
+			if err := recover(); err != nil {
+				t.Errorf("panic: %v (input: %x)", err, data)
+			}
+		}()
+
+		// decode
+		n, _ := fpack.DecodePartial(data, decode)
+		if n < 0 || n > len(data) {
+			t.Errorf("consumed %d bytes of %d bytes (input: %x)", n, len(data), data)
+		}
+	})
+}
@@ -0,0 +1,40 @@
+package fpacktest
+
+import (
+	"testing"
+
+	"github.com/256dpi/fpack"
+)
+
+type point struct {
+	X, Y int32
+	Tag  string
+}
+
+func encodePoint(enc *fpack.Encoder, p point) {
+	enc.Int32(p.X)
+	enc.Int32(p.Y)
+	enc.VarString(p.Tag)
+}
+
+func decodePoint(dec *fpack.Decoder) point {
+	return point{
+		X:   dec.Int32(),
+		Y:   dec.Int32(),
+		Tag: dec.VarString(true),
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	RoundTrip(t, encodePoint, decodePoint, point{}, point{X: 1, Y: -1, Tag: "foo"})
+}
+
+func FuzzPoint(f *testing.F) {
+	f.Add([]byte{0, 0, 0, 1, 0, 0, 0, 2, 3, 'f', 'o', 'o'})
+	f.Add([]byte{0, 0, 0, 1, 0xFF})
+	f.Add([]byte("00000000\xb1\xb1\xb1\xb1\xb1\xb1\xb1\xb1\xe4\x01"))
+	FuzzDecode(f, func(dec *fpack.Decoder) error {
+		decodePoint(dec)
+		return nil
+	})
+}
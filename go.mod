@@ -2,10 +2,7 @@ module github.com/256dpi/fpack
 
 go 1.18
 
-require (
-	github.com/stretchr/testify v1.7.0
-	github.com/tidwall/cast v0.0.0-20201103180942-206472522f97
-)
+require github.com/stretchr/testify v1.7.0
 
 require (
 	github.com/davecgh/go-spew v1.1.0 // indirect
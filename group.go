@@ -0,0 +1,156 @@
+package fpack
+
+func groupSize(num uint32) int {
+	switch {
+	case num < 1<<8:
+		return 1
+	case num < 1<<16:
+		return 2
+	case num < 1<<24:
+		return 3
+	default:
+		return 4
+	}
+}
+
+// GroupUint32 writes four unsigned integers using group varint encoding. A
+// control byte stores the byte length minus one of each value in two bits,
+// starting with the first value in the lowest bits. The values follow using
+// the minimal amount of bytes in little endian byte order.
+func (e *Encoder) GroupUint32(a, b, c, d uint32) {
+	// skip if errored
+	if e.err != nil {
+		return
+	}
+
+	// determine sizes
+	nums := [4]uint32{a, b, c, d}
+	sizes := [4]int{groupSize(a), groupSize(b), groupSize(c), groupSize(d)}
+	total := 1 + sizes[0] + sizes[1] + sizes[2] + sizes[3]
+
+	// handle length
+	if e.buf == nil {
+		if e.rcd {
+			e.render(total, func(enc *Encoder) {
+				enc.GroupUint32(a, b, c, d)
+			})
+		}
+		e.len += total
+		return
+	}
+
+	// grow buffer
+	if !e.grow("group", total) {
+		return
+	}
+
+	// write control byte
+	e.buf[0] = byte(sizes[0]-1) | byte(sizes[1]-1)<<2 | byte(sizes[2]-1)<<4 | byte(sizes[3]-1)<<6
+
+	// write values
+	pos := 1
+	for i, num := range nums {
+		for j := 0; j < sizes[i]; j++ {
+			e.buf[pos] = byte(num >> (j * 8))
+			pos++
+		}
+	}
+
+	// trace
+	if e.trc != nil {
+		e.trc("group", e.Offset(), total)
+	}
+
+	// slice
+	e.buf = e.buf[total:]
+}
+
+// GroupUint32Slice writes a variable count prefixed slice of unsigned integers
+// using group varint encoding. The last group is padded with zeros.
+func (e *Encoder) GroupUint32Slice(nums []uint32) {
+	// write count
+	e.VarUint(uint64(len(nums)))
+
+	// write groups
+	for i := 0; i < len(nums); i += 4 {
+		var group [4]uint32
+		copy(group[:], nums[i:])
+		e.GroupUint32(group[0], group[1], group[2], group[3])
+	}
+}
+
+// GroupUint32 reads four unsigned integers using group varint encoding.
+func (d *Decoder) GroupUint32() [4]uint32 {
+	// skip if errored
+	if d.err != nil {
+		return [4]uint32{}
+	}
+
+	// read control byte
+	if !d.need(1) {
+		d.fail(ErrBufferTooShort, "groupuint32", 1)
+		return [4]uint32{}
+	}
+	ctrl := d.buf[0]
+
+	// determine total
+	total := 1
+	for i := 0; i < 4; i++ {
+		total += int(ctrl>>(i*2)&3) + 1
+	}
+
+	// check length
+	if !d.need(total) {
+		d.fail(ErrBufferTooShort, "groupuint32", total)
+		return [4]uint32{}
+	}
+
+	// read values
+	var nums [4]uint32
+	pos := 1
+	for i := range nums {
+		size := int(ctrl>>(i*2)&3) + 1
+		for j := 0; j < size; j++ {
+			nums[i] |= uint32(d.buf[pos]) << (j * 8)
+			pos++
+		}
+	}
+
+	// trace
+	if d.trc != nil {
+		d.trc("group", d.Offset(), total, nums)
+	}
+
+	// slice
+	d.buf = d.buf[total:]
+
+	return nums
+}
+
+// GroupUint32Slice reads a variable count prefixed slice of unsigned integers
+// using group varint encoding. It sets ErrListTooLong if the count exceeds the
+// specified maximum.
+func (d *Decoder) GroupUint32Slice(maxLen int) []uint32 {
+	// read count
+	num := d.varuint("groupuint32slice")
+	if d.err != nil {
+		return nil
+	}
+
+	// check count
+	if !d.count("groupuint32slice", num, maxLen) {
+		return nil
+	}
+
+	// read groups
+	nums := make([]uint32, 0, (num+3)/4*4)
+	for i := uint64(0); i < num; i += 4 {
+		group := d.GroupUint32()
+		if d.err != nil {
+			return nil
+		}
+		nums = append(nums, group[:]...)
+	}
+
+	return nums[:num]
+}
@@ -0,0 +1,107 @@
+package fpack
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroupUint32(t *testing.T) {
+	buf, _, err := Encode(nil, func(enc *Encoder) error {
+		enc.GroupUint32(1, 256, 1<<16, math.MaxUint32)
+		enc.GroupUint32(0, 0, 0, 0)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{
+		0xE4, 1, 0, 1, 0, 0, 1, 0xFF, 0xFF, 0xFF, 0xFF,
+		0x00, 0, 0, 0, 0,
+	}, buf)
+
+	var group1, group2 [4]uint32
+	err = Decode(buf, func(dec *Decoder) error {
+		group1 = dec.GroupUint32()
+		group2 = dec.GroupUint32()
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, [4]uint32{1, 256, 1 << 16, math.MaxUint32}, group1)
+	assert.Equal(t, [4]uint32{}, group2)
+
+	err = Decode(buf[:10], func(dec *Decoder) error {
+		dec.GroupUint32()
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrBufferTooShort)
+}
+
+func TestGroupUint32Slice(t *testing.T) {
+	for _, nums := range [][]uint32{{}, {1}, {1, 2, 3, 4}, {1, 2, 3, 4, 5, 1000, 1 << 30}} {
+		buf, _, err := Encode(nil, func(enc *Encoder) error {
+			enc.GroupUint32Slice(nums)
+			return nil
+		})
+		assert.NoError(t, err)
+
+		var res []uint32
+		err = Decode(buf, func(dec *Decoder) error {
+			res = dec.GroupUint32Slice(10)
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, nums, res)
+
+		err = Decode(buf, func(dec *Decoder) error {
+			dec.GroupUint32Slice(len(nums) - 1)
+			return nil
+		})
+		if len(nums) > 0 {
+			assert.ErrorIs(t, err, ErrListTooLong)
+		}
+	}
+}
+
+func BenchmarkGroupUint32(b *testing.B) {
+	buf, _, err := Encode(nil, func(enc *Encoder) error {
+		enc.GroupUint32(1, 300, 70000, 1<<30)
+		return nil
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	dec := NewDecoder(nil)
+	for i := 0; i < b.N; i++ {
+		dec.Reset(buf)
+		dec.GroupUint32()
+	}
+}
+
+func BenchmarkGroupUint32VarUint(b *testing.B) {
+	buf, _, err := Encode(nil, func(enc *Encoder) error {
+		enc.VarUint(1)
+		enc.VarUint(300)
+		enc.VarUint(70000)
+		enc.VarUint(1 << 30)
+		return nil
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	dec := NewDecoder(nil)
+	for i := 0; i < b.N; i++ {
+		dec.Reset(buf)
+		dec.VarUint()
+		dec.VarUint()
+		dec.VarUint()
+		dec.VarUint()
+	}
+}
@@ -0,0 +1,90 @@
+package fpack
+
+import (
+	"crypto/hmac"
+	"errors"
+	"hash"
+)
+
+// ErrAuthFailed is returned if a decoded HMAC does not match.
+var ErrAuthFailed = errors.New("authentication failed")
+
+// HMAC writes an HMAC over all bytes previously written since the last reset
+// using the provided hash function and key.
+func (e *Encoder) HMAC(h func() hash.Hash, key []byte) {
+	// skip if errored
+	if e.err != nil {
+		return
+	}
+
+	// handle length
+	if e.buf == nil {
+		if e.rcd {
+			e.record(op{code: opFunc, fn: func(enc *Encoder) {
+				enc.HMAC(h, key)
+			}})
+		}
+		e.len += h().Size()
+		return
+	}
+
+	// compute HMAC
+	mac := hmac.New(h, key)
+	if e.snk != nil {
+		e.flush()
+		e.snk.Range(e.sbs, e.fls, func(_ int, data []byte) {
+			mac.Write(data)
+		})
+	} else {
+		mac.Write(e.org[:e.Offset()])
+	}
+
+	// write HMAC
+	e.Bytes(mac.Sum(nil))
+}
+
+// HMAC reads an HMAC and compares it in constant time with an HMAC computed
+// over all bytes previously consumed since the last reset using the provided
+// hash function and key. A mismatch results in ErrAuthFailed.
+func (d *Decoder) HMAC(h func() hash.Hash, key []byte) {
+	// skip if errored
+	if d.err != nil {
+		return
+	}
+
+	// compute HMAC
+	mac := hmac.New(h, key)
+	if d.src != nil {
+		off := d.Offset()
+		for _, buf := range d.src {
+			if len(buf) > off {
+				buf = buf[:off]
+			}
+			mac.Write(buf)
+			off -= len(buf)
+		}
+	} else {
+		mac.Write(d.org[:d.Offset()])
+	}
+	sum := mac.Sum(nil)
+
+	// check length
+	if !d.need(len(sum)) {
+		d.fail(ErrBufferTooShort, "hmac", len(sum))
+		return
+	}
+
+	// compare HMAC
+	if !hmac.Equal(sum, d.buf[:len(sum)]) {
+		d.fail(ErrAuthFailed, "hmac", len(sum))
+		return
+	}
+
+	// trace
+	if d.trc != nil {
+		d.trc("hmac", d.Offset(), len(sum), sum)
+	}
+
+	// slice
+	d.buf = d.buf[len(sum):]
+}
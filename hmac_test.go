@@ -0,0 +1,56 @@
+package fpack
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHMAC(t *testing.T) {
+	key := []byte("secret")
+
+	buf, _, err := Encode(nil, func(enc *Encoder) error {
+		enc.VarString("hello")
+		enc.HMAC(sha256.New, key)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Len(t, buf, 6+32)
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("\x05hello"))
+	assert.Equal(t, mac.Sum(nil), buf[6:])
+
+	var str string
+	err = Decode(buf, func(dec *Decoder) error {
+		str = dec.VarString(false)
+		dec.HMAC(sha256.New, key)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", str)
+
+	err = Decode(buf, func(dec *Decoder) error {
+		dec.VarString(false)
+		dec.HMAC(sha256.New, []byte("wrong"))
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrAuthFailed)
+
+	buf[1] = 'j'
+	err = Decode(buf, func(dec *Decoder) error {
+		dec.VarString(false)
+		dec.HMAC(sha256.New, key)
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrAuthFailed)
+
+	err = Decode(buf[:20], func(dec *Decoder) error {
+		dec.VarString(false)
+		dec.HMAC(sha256.New, key)
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrBufferTooShort)
+}
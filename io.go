@@ -0,0 +1,138 @@
+package fpack
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+)
+
+// ErrEmptyFrame is returned if a frame has no payload.
+var ErrEmptyFrame = errors.New("empty frame")
+
+// EncodeTo will encode data using the provided encoding function and write it
+// to the provided writer. The buffer is borrowed from the pool if provided and
+// released before returning. It returns the number of written bytes.
+func EncodeTo(w io.Writer, pool *Pool, fn func(enc *Encoder) error) (int, error) {
+	// encode
+	buf, ref, err := Encode(pool, fn)
+	if err != nil {
+		return 0, err
+	}
+
+	// ensure release
+	defer ref.Release()
+
+	// write
+	n, err := w.Write(buf)
+	if err == nil && n < len(buf) {
+		err = io.ErrShortWrite
+	}
+
+	return n, err
+}
+
+// DecodeFrom will read a four byte big endian length prefixed message from the
+// provided reader and decode it using the provided decoding function. Lengths
+// above the specified maximum result in ErrLengthLimit. Incomplete messages
+// result in io.ErrUnexpectedEOF. The message buffer is borrowed from the pool
+// if provided and released before returning. Therefore, decoded strings and
+// byte slices must be cloned to be used after the call.
+func DecodeFrom(r io.Reader, pool *Pool, maxSize int, fn func(dec *Decoder) error) error {
+	// read frame
+	buf, ref, err := readFrame(r, pool, maxSize, true)
+	if err != nil {
+		return err
+	}
+
+	// ensure release
+	defer ref.Release()
+
+	return Decode(buf, fn)
+}
+
+// WriteFrame will encode data using the provided encoding function and write
+// it with a four byte big endian length prefix to the provided writer using a
+// single write. The buffer is borrowed from the pool if provided and released
+// before returning. Empty payloads result in ErrEmptyFrame and payloads that
+// exceed the prefix in ErrLengthLimit.
+func WriteFrame(w io.Writer, pool *Pool, fn func(enc *Encoder) error) error {
+	// encode
+	buf, ref, err := Encode(pool, func(enc *Encoder) error {
+		enc.Uint32(0)
+		return fn(enc)
+	})
+	if err != nil {
+		return err
+	}
+
+	// ensure release
+	defer ref.Release()
+
+	// check length
+	length := len(buf) - 4
+	if length == 0 {
+		return ErrEmptyFrame
+	} else if uint64(length) > math.MaxUint32 {
+		return ErrLengthLimit
+	}
+
+	// write length
+	binary.BigEndian.PutUint32(buf, uint32(length))
+
+	// write
+	n, err := w.Write(buf)
+	if err == nil && n < len(buf) {
+		err = io.ErrShortWrite
+	}
+
+	return err
+}
+
+// ReadFrame will read a four byte big endian length prefixed frame written by
+// WriteFrame from the provided reader. The returned buffer is borrowed from the
+// pool if provided and owned by the returned Ref. A clean end of the stream
+// before a frame results in io.EOF while incomplete frames result in
+// io.ErrUnexpectedEOF. Empty frames result in ErrEmptyFrame and lengths above
+// the specified maximum in ErrLengthLimit.
+func ReadFrame(r io.Reader, pool *Pool, maxSize int) ([]byte, Ref, error) {
+	return readFrame(r, pool, maxSize, false)
+}
+
+func readFrame(r io.Reader, pool *Pool, maxSize int, allowEmpty bool) ([]byte, Ref, error) {
+	// read length
+	var pre [4]byte
+	_, err := io.ReadFull(r, pre[:])
+	length := binary.BigEndian.Uint32(pre[:])
+	if err != nil {
+		return nil, Ref{}, err
+	}
+
+	// check length
+	if length == 0 && !allowEmpty {
+		return nil, Ref{}, ErrEmptyFrame
+	} else if uint64(length) > uint64(maxSize) {
+		return nil, Ref{}, ErrLengthLimit
+	}
+
+	// get buffer
+	var buf []byte
+	var ref Ref
+	if pool != nil {
+		buf, ref = pool.Borrow(int(length), false)
+	} else {
+		buf = make([]byte, length)
+	}
+
+	// read frame
+	_, err = io.ReadFull(r, buf)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		ref.Release()
+		return nil, Ref{}, err
+	}
+
+	return buf, ref, nil
+}
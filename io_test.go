@@ -0,0 +1,164 @@
+package fpack
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeTo(t *testing.T) {
+	withAndWithoutPool(func(pool *Pool) {
+		var buf bytes.Buffer
+		n, err := EncodeTo(&buf, pool, func(enc *Encoder) error {
+			enc.Uint8(1)
+			enc.String("foo")
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 4, n)
+		assert.Equal(t, []byte("\x01foo"), buf.Bytes())
+
+		n, err = EncodeTo(&buf, pool, func(enc *Encoder) error {
+			return io.EOF
+		})
+		assert.Equal(t, io.EOF, err)
+		assert.Equal(t, 0, n)
+
+		n, err = EncodeTo(shortWriter{}, pool, func(enc *Encoder) error {
+			enc.String("foo")
+			return nil
+		})
+		assert.Equal(t, io.ErrShortWrite, err)
+		assert.Equal(t, 1, n)
+	})
+}
+
+func TestDecodeFrom(t *testing.T) {
+	withAndWithoutPool(func(pool *Pool) {
+		r := bytes.NewReader([]byte{0, 0, 0, 3, 1, 2, 3, 0, 0, 0, 0})
+
+		var num uint8
+		var str string
+		err := DecodeFrom(r, pool, 10, func(dec *Decoder) error {
+			num = dec.Uint8()
+			str = dec.TailString(true)
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, uint8(1), num)
+		assert.Equal(t, "\x02\x03", str)
+
+		err = DecodeFrom(r, pool, 10, func(dec *Decoder) error {
+			return nil
+		})
+		assert.NoError(t, err)
+
+		err = DecodeFrom(r, pool, 10, func(dec *Decoder) error {
+			return nil
+		})
+		assert.Equal(t, io.EOF, err)
+
+		r = bytes.NewReader([]byte{0, 0, 0, 11})
+		err = DecodeFrom(r, pool, 10, func(dec *Decoder) error {
+			return nil
+		})
+		assert.Equal(t, ErrLengthLimit, err)
+
+		r = bytes.NewReader([]byte{0, 0, 0, 3, 1, 2})
+		err = DecodeFrom(r, pool, 10, func(dec *Decoder) error {
+			return nil
+		})
+		assert.Equal(t, io.ErrUnexpectedEOF, err)
+
+		r = bytes.NewReader([]byte{0, 0, 0, 3})
+		err = DecodeFrom(r, pool, 10, func(dec *Decoder) error {
+			return nil
+		})
+		assert.Equal(t, io.ErrUnexpectedEOF, err)
+
+		r = bytes.NewReader([]byte{0, 0, 0, 2, 1, 2})
+		err = DecodeFrom(r, pool, 10, func(dec *Decoder) error {
+			dec.Uint8()
+			return nil
+		})
+		assert.ErrorIs(t, err, ErrRemainingBytes)
+	})
+}
+
+func TestWriteReadFrame(t *testing.T) {
+	withAndWithoutPool(func(pool *Pool) {
+		var buf bytes.Buffer
+		err := WriteFrame(&buf, pool, func(enc *Encoder) error {
+			enc.UseLittleEndian()
+			enc.Uint16(1)
+			enc.String("foo")
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("\x00\x00\x00\x05\x01\x00foo"), buf.Bytes())
+
+		err = WriteFrame(&buf, pool, func(enc *Encoder) error {
+			return nil
+		})
+		assert.Equal(t, ErrEmptyFrame, err)
+
+		err = WriteFrame(&buf, pool, func(enc *Encoder) error {
+			return io.EOF
+		})
+		assert.Equal(t, io.EOF, err)
+
+		err = WriteFrame(shortWriter{}, pool, func(enc *Encoder) error {
+			enc.String("foo")
+			return nil
+		})
+		assert.Equal(t, io.ErrShortWrite, err)
+
+		frame, ref, err := ReadFrame(&buf, pool, 10)
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("\x01\x00foo"), frame)
+		ref.Release()
+
+		frame, ref, err = ReadFrame(&buf, pool, 10)
+		assert.Equal(t, io.EOF, err)
+		assert.Nil(t, frame)
+		assert.Zero(t, ref)
+
+		frame, _, err = ReadFrame(bytes.NewReader([]byte{0, 0, 0, 0}), pool, 10)
+		assert.Equal(t, ErrEmptyFrame, err)
+		assert.Nil(t, frame)
+
+		frame, _, err = ReadFrame(bytes.NewReader([]byte{0, 0, 0, 11}), pool, 10)
+		assert.Equal(t, ErrLengthLimit, err)
+		assert.Nil(t, frame)
+
+		frame, _, err = ReadFrame(bytes.NewReader([]byte{0, 0, 0, 3, 1, 2}), pool, 10)
+		assert.Equal(t, io.ErrUnexpectedEOF, err)
+		assert.Nil(t, frame)
+
+		frame, _, err = ReadFrame(bytes.NewReader([]byte{0, 0}), pool, 10)
+		assert.Equal(t, io.ErrUnexpectedEOF, err)
+		assert.Nil(t, frame)
+	})
+}
+
+func TestWriteReadFrameAllocation(t *testing.T) {
+	if raceEnabled {
+		t.Skip()
+	}
+
+	// the length prefix read escapes to the heap
+	var buf bytes.Buffer
+	buf.Grow(64)
+	assert.Equal(t, 1.0, testing.AllocsPerRun(100, func() {
+		buf.Reset()
+		_ = WriteFrame(&buf, Global(), func(enc *Encoder) error {
+			enc.Uint64(42)
+			enc.Uint64(42)
+			return nil
+		})
+		_, ref, _ := ReadFrame(&buf, Global(), 16)
+		ref.Release()
+	}))
+}
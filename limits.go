@@ -0,0 +1,120 @@
+package fpack
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ErrLimitExceeded is returned if a limit configured using Harden is exceeded.
+var ErrLimitExceeded = errors.New("limit exceeded")
+
+// LimitError is set if a limit configured using Harden is exceeded. It matches
+// ErrLimitExceeded using errors.Is.
+type LimitError struct {
+	Limit string
+	Value uint64
+	Max   int
+}
+
+// Error implements the error interface.
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("limit exceeded: %s %d > %d", e.Limit, e.Value, e.Max)
+}
+
+// Unwrap returns ErrLimitExceeded.
+func (e *LimitError) Unwrap() error {
+	return ErrLimitExceeded
+}
+
+// Limits defines limits for decoding untrusted data. Zero values disable the
+// respective limit.
+type Limits struct {
+	// MaxLength is the maximum length announced by the length prefix of
+	// FixString, FixBytes, VarString, VarBytes, HexBytes and the uncompressed
+	// length of CompressedBlock.
+	MaxLength int
+
+	// MaxItems is the maximum item count of List, GroupUint32Slice and the
+	// delta slices.
+	MaxItems int
+
+	// MaxDepth is the maximum nesting depth of List, Option, Limit and
+	// CompressedBlock.
+	MaxDepth int
+}
+
+// Harden will enable the provided limits. Exceeded limits set a LimitError.
+// Reset clears the limits.
+func (d *Decoder) Harden(limits Limits) {
+	d.lim = limits
+}
+
+// maxInt is the largest length that can be represented as an int.
+var maxInt uint64 = math.MaxInt
+
+func (d *Decoder) length(op string, num uint64) int {
+	// skip if errored
+	if d.err != nil {
+		return 0
+	}
+
+	// check size
+	if num > maxInt {
+		d.fail(ErrInvalidSize, op, 0)
+		return 0
+	}
+
+	// check maximum
+	if d.max > 0 && num > uint64(d.max) {
+		d.fail(ErrLengthLimit, op, int(num))
+		return 0
+	}
+
+	// check limit
+	if d.lim.MaxLength > 0 && num > uint64(d.lim.MaxLength) {
+		d.fail(&LimitError{Limit: "length", Value: num, Max: d.lim.MaxLength}, op, int(num))
+		return 0
+	}
+
+	return int(num)
+}
+
+func (d *Decoder) count(op string, num uint64, maxLen int) bool {
+	// check maximum
+	if maxLen < 0 {
+		d.fail(ErrInvalidLength, op, 0)
+		return false
+	}
+
+	// check limit
+	if d.lim.MaxItems > 0 && num > uint64(d.lim.MaxItems) {
+		d.fail(&LimitError{Limit: "items", Value: num, Max: d.lim.MaxItems}, op, 0)
+		return false
+	}
+
+	// check count
+	if num > uint64(maxLen) {
+		d.fail(ErrListTooLong, op, 0)
+		return false
+	}
+
+	return true
+}
+
+func (d *Decoder) enter(op string) bool {
+	// check depth
+	if d.lim.MaxDepth > 0 && d.dep >= d.lim.MaxDepth {
+		d.fail(&LimitError{Limit: "depth", Value: uint64(d.dep + 1), Max: d.lim.MaxDepth}, op, 0)
+		return false
+	}
+
+	// increment depth
+	d.dep++
+
+	return true
+}
+
+func (d *Decoder) leave() {
+	d.dep--
+}
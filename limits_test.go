@@ -0,0 +1,93 @@
+package fpack
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecoderHarden(t *testing.T) {
+	limits := Limits{MaxLength: 3, MaxItems: 2, MaxDepth: 2}
+
+	err := Decode([]byte{3, 'f', 'o', 'o', 2, 1, 1, 1, 1}, func(dec *Decoder) error {
+		dec.Harden(limits)
+		assert.Equal(t, "foo", dec.VarString(false))
+		dec.List(10, func(dec *Decoder, i int) error {
+			dec.Option(func(dec *Decoder) {
+				dec.Uint8()
+			})
+			return nil
+		})
+		return nil
+	})
+	assert.NoError(t, err)
+
+	err = Decode([]byte{4, 'f', 'o', 'o', 'o'}, func(dec *Decoder) error {
+		dec.Harden(limits)
+		dec.VarString(false)
+		return nil
+	})
+	assert.Equal(t, &DecodeError{Offset: 1, Op: "varstring", Length: 4, Err: &LimitError{Limit: "length", Value: 4, Max: 3}}, err)
+	assert.EqualError(t, err, "varstring at offset 1 (length 4): limit exceeded: length 4 > 3")
+	assert.True(t, errors.Is(err, ErrLimitExceeded))
+
+	err = Decode([]byte{0, 4, 'f', 'o', 'o', 'o'}, func(dec *Decoder) error {
+		dec.Harden(limits)
+		dec.FixBytes(2, false)
+		return nil
+	})
+	assert.Equal(t, &DecodeError{Offset: 2, Op: "fixbytes", Length: 4, Err: &LimitError{Limit: "length", Value: 4, Max: 3}}, err)
+
+	err = Decode([]byte{3, 1, 2, 3}, func(dec *Decoder) error {
+		dec.Harden(limits)
+		dec.List(10, func(dec *Decoder, i int) error {
+			dec.Uint8()
+			return nil
+		})
+		return nil
+	})
+	assert.Equal(t, &DecodeError{Offset: 1, Op: "list", Err: &LimitError{Limit: "items", Value: 3, Max: 2}}, err)
+
+	err = Decode([]byte{3, 1, 2, 3}, func(dec *Decoder) error {
+		dec.Harden(limits)
+		dec.GroupUint32Slice(10)
+		return nil
+	})
+	assert.Equal(t, &DecodeError{Offset: 1, Op: "groupuint32slice", Err: &LimitError{Limit: "items", Value: 3, Max: 2}}, err)
+
+	err = Decode([]byte{1, 1, 1, 1}, func(dec *Decoder) error {
+		dec.Harden(limits)
+		dec.Option(func(dec *Decoder) {
+			dec.Option(func(dec *Decoder) {
+				dec.Option(func(dec *Decoder) {
+					dec.Uint8()
+				})
+			})
+		})
+		return nil
+	})
+	assert.Equal(t, &DecodeError{Offset: 3, Op: "option", Err: &LimitError{Limit: "depth", Value: 3, Max: 2}}, err)
+
+	err = Decode([]byte{1, 5}, func(dec *Decoder) error {
+		dec.Harden(limits)
+		dec.Limit(2, func(dec *Decoder) error {
+			dec.List(1, func(dec *Decoder, i int) error {
+				dec.Limit(1, func(dec *Decoder) error {
+					dec.Uint8()
+					return nil
+				})
+				return nil
+			})
+			return nil
+		})
+		return nil
+	})
+	assert.Equal(t, &DecodeError{Offset: 1, Op: "limit", Err: &LimitError{Limit: "depth", Value: 3, Max: 2}}, err)
+
+	err = Decode([]byte{4, 'f', 'o', 'o', 'o'}, func(dec *Decoder) error {
+		dec.VarString(false)
+		return nil
+	})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,110 @@
+package fpack
+
+import (
+	"errors"
+	"math"
+	"strconv"
+)
+
+// ErrInvalidNetstringLength is returned if a netstring length is malformed.
+var ErrInvalidNetstringLength = errors.New("invalid netstring length")
+
+// ErrInvalidNetstringDelimiter is returned if a netstring colon or comma is
+// missing.
+var ErrInvalidNetstringDelimiter = errors.New("invalid netstring delimiter")
+
+// ErrNetstringTooLong is returned if a netstring length exceeds the configured
+// limit.
+var ErrNetstringTooLong = errors.New("netstring too long")
+
+// Netstring writes a netstring ("<len>:<data>,").
+func (e *Encoder) Netstring(buf []byte) {
+	// skip if errored
+	if e.err != nil {
+		return
+	}
+
+	// format length
+	var num [20]byte
+	length := strconv.AppendInt(num[:0], int64(len(buf)), 10)
+
+	// encode
+	e.Bytes(length)
+	e.Uint8(':')
+	e.Bytes(buf)
+	e.Uint8(',')
+}
+
+// SetNetstringLimit will set the maximum length of decoded netstrings. A zero
+// limit disables the check.
+func (d *Decoder) SetNetstringLimit(limit int) {
+	d.nsl = limit
+}
+
+// Netstring reads a netstring ("<len>:<data>,"). If the byte slice is not
+// cloned it may change if the source byte slice changes.
+func (d *Decoder) Netstring(clone bool) []byte {
+	// skip if errored
+	if d.err != nil {
+		return nil
+	}
+
+	// stitch following slices
+	d.need(d.Length())
+
+	// parse length
+	var length, i int
+	for ; i < len(d.buf) && d.buf[i] >= '0' && d.buf[i] <= '9'; i++ {
+		// check leading zero
+		if i == 1 && d.buf[0] == '0' {
+			d.fail(ErrInvalidNetstringLength, "netstring", 0)
+			return nil
+		}
+
+		// check overflow
+		if length > (math.MaxInt-9)/10 {
+			d.fail(ErrNetstringTooLong, "netstring", 0)
+			return nil
+		}
+
+		// add digit
+		length = length*10 + int(d.buf[i]-'0')
+	}
+
+	// check colon
+	if i == len(d.buf) {
+		d.fail(ErrBufferTooShort, "netstring", 0)
+		return nil
+	} else if i == 0 {
+		d.fail(ErrInvalidNetstringLength, "netstring", 0)
+		return nil
+	} else if d.buf[i] != ':' {
+		d.fail(ErrInvalidNetstringDelimiter, "netstring", 0)
+		return nil
+	}
+
+	// check limit
+	if d.nsl > 0 && length > d.nsl {
+		d.fail(ErrNetstringTooLong, "netstring", length)
+		return nil
+	}
+
+	// check length
+	if len(d.buf)-i-1 < length+1 {
+		d.fail(ErrBufferTooShort, "netstring", length+1)
+		return nil
+	}
+
+	// check comma
+	if d.buf[i+1+length] != ',' {
+		d.fail(ErrInvalidNetstringDelimiter, "netstring", length+1)
+		return nil
+	}
+
+	// decode
+	d.Skip(i + 1)
+	buf := d.bytes("netstring", length, clone)
+	d.Skip(1)
+
+	return buf
+}
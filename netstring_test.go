@@ -0,0 +1,62 @@
+package fpack
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNetstring(t *testing.T) {
+	long := strings.Repeat("x", 12345)
+
+	buf, _, err := Encode(nil, func(enc *Encoder) error {
+		enc.Netstring([]byte("hello world!"))
+		enc.Netstring(nil)
+		enc.Netstring([]byte(long))
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "12:hello world!,0:,12345:"+long+",", string(buf))
+
+	var res1, res2, res3 []byte
+	err = Decode(buf, func(dec *Decoder) error {
+		res1 = dec.Netstring(false)
+		res2 = dec.Netstring(true)
+		res3 = dec.Netstring(true)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "hello world!", string(res1))
+	assert.Equal(t, []byte{}, res2)
+	assert.Equal(t, long, string(res3))
+}
+
+func TestNetstringErrors(t *testing.T) {
+	table := []struct {
+		data  string
+		limit int
+		err   error
+	}{
+		{data: "", err: ErrBufferTooShort},
+		{data: "3", err: ErrBufferTooShort},
+		{data: "3:fo", err: ErrBufferTooShort},
+		{data: "3:foo", err: ErrBufferTooShort},
+		{data: ":foo,", err: ErrInvalidNetstringLength},
+		{data: "03:foo,", err: ErrInvalidNetstringLength},
+		{data: "x3:foo,", err: ErrInvalidNetstringLength},
+		{data: "3;foo,", err: ErrInvalidNetstringDelimiter},
+		{data: "3:foo;", err: ErrInvalidNetstringDelimiter},
+		{data: "3:foo,", limit: 2, err: ErrNetstringTooLong},
+		{data: "99999999999999999999:", err: ErrNetstringTooLong},
+	}
+
+	for i, item := range table {
+		err := Decode([]byte(item.data), func(dec *Decoder) error {
+			dec.SetNetstringLimit(item.limit)
+			dec.Netstring(false)
+			return nil
+		})
+		assert.ErrorIs(t, err, item.err, i)
+	}
+}
@@ -0,0 +1,5 @@
+//go:build !race
+
+package fpack
+
+const raceEnabled = false
@@ -0,0 +1,61 @@
+package fpack
+
+import "encoding/binary"
+
+// Options configures encoding and decoding using EncodeWith and DecodeWith.
+// The options are applied before the callback runs and pooled encoders and
+// decoders are fully reset afterwards.
+type Options struct {
+	// Pool is used to borrow the encoding buffer.
+	Pool *Pool
+
+	// Arena is used to allocate the encoding buffer and to clone decoded
+	// strings and byte slices. It takes precedence over the pool.
+	Arena *Arena
+
+	// ByteOrder sets the used binary byte order. Defaults to big endian.
+	ByteOrder binary.ByteOrder
+
+	// ValidateUTF8 enables UTF-8 validation of decoded strings.
+	ValidateUTF8 bool
+
+	// AllowRemaining allows the decoded buffer to not be fully consumed.
+	AllowRemaining bool
+
+	// PlainErrors disables detailed decoding errors and returns the plain
+	// errors like ErrBufferTooShort instead of a DecodeError.
+	PlainErrors bool
+
+	// MaxLength sets the maximum length of length prefixed reads.
+	MaxLength int
+}
+
+func (o *Options) applyEncoder(enc *Encoder) {
+	if o.ByteOrder != nil {
+		enc.bo = o.ByteOrder
+	}
+}
+
+func (o *Options) applyDecoder(dec *Decoder) {
+	if o.ByteOrder != nil {
+		dec.bo = o.ByteOrder
+	}
+	dec.arn = o.Arena
+	dec.utf = o.ValidateUTF8
+	dec.rem = o.AllowRemaining
+	dec.det = !o.PlainErrors
+	dec.max = o.MaxLength
+}
+
+// EncodeWith will encode data like Encode using the provided options. If an
+// arena is configured, the returned Ref is empty.
+func EncodeWith(opts Options, fn func(enc *Encoder) error) ([]byte, Ref, error) {
+	buf, _, ref, err := encode(opts, nil, false, fn)
+	return buf, ref, err
+}
+
+// DecodeWith will decode data like Decode using the provided options.
+func DecodeWith(bytes []byte, opts Options, fn func(dec *Decoder) error) error {
+	_, err := decode(bytes, opts, fn)
+	return err
+}
@@ -0,0 +1,83 @@
+package fpack
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeWith(t *testing.T) {
+	withAndWithoutPool(func(pool *Pool) {
+		buf, ref, err := EncodeWith(Options{
+			Pool:      pool,
+			ByteOrder: binary.LittleEndian,
+		}, func(enc *Encoder) error {
+			enc.Uint16(1)
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []byte{1, 0}, buf)
+		ref.Release()
+	})
+
+	arena := NewArena(Global(), 64)
+	buf, ref, err := EncodeWith(Options{
+		Arena: arena,
+	}, func(enc *Encoder) error {
+		enc.Uint16(1)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0, 1}, buf)
+	assert.Equal(t, Ref{}, ref)
+	assert.Equal(t, 2, arena.Length())
+	arena.Release()
+
+	buf, _, err = Encode(nil, func(enc *Encoder) error {
+		enc.Uint16(1)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0, 1}, buf)
+}
+
+func TestDecodeWith(t *testing.T) {
+	arena := NewArena(Global(), 64)
+	err := DecodeWith([]byte{1, 0, 1, 'a', 2}, Options{
+		Arena:          arena,
+		ByteOrder:      binary.LittleEndian,
+		AllowRemaining: true,
+	}, func(dec *Decoder) error {
+		assert.Equal(t, uint16(1), dec.Uint16())
+		assert.Equal(t, "a", dec.VarString(true))
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, arena.Length())
+	arena.Release()
+
+	err = DecodeWith([]byte{1, 0xFF}, Options{
+		ValidateUTF8: true,
+	}, func(dec *Decoder) error {
+		dec.VarString(false)
+		return nil
+	})
+	assert.Equal(t, &DecodeError{Offset: 1, Op: "varstring", Length: 1, Err: ErrInvalidUTF8}, err)
+
+	err = DecodeWith([]byte{1, 0xFF}, Options{
+		ValidateUTF8: true,
+		PlainErrors:  true,
+	}, func(dec *Decoder) error {
+		dec.VarString(false)
+		return nil
+	})
+	assert.Equal(t, ErrInvalidUTF8, err)
+
+	err = Decode([]byte{1, 0, 1, 0xFF, 2}, func(dec *Decoder) error {
+		assert.Equal(t, uint16(256), dec.Uint16())
+		assert.Equal(t, "\xFF", dec.VarString(false))
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrRemainingBytes)
+}
@@ -0,0 +1,338 @@
+package fpack
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"strings"
+)
+
+// ErrNotANumber is returned if an ordered float is NaN.
+var ErrNotANumber = errors.New("not a number")
+
+// OrderedString writes a string that preserves the byte-wise ordering of the
+// original strings. Every 0x00 byte is escaped as 0x00 0xFF and the string is
+// terminated by 0x00 0x01. This ensures that a string sorts before all strings
+// it is a prefix of.
+func (e *Encoder) OrderedString(str string) {
+	// skip if errored
+	if e.err != nil {
+		return
+	}
+
+	// write escaped bytes
+	buf := toBytes(str)
+	for len(buf) > 0 {
+		// find zero byte
+		idx := bytes.IndexByte(buf, 0)
+		if idx < 0 {
+			e.Bytes(buf)
+			break
+		}
+
+		// write bytes and escape
+		e.Bytes(buf[:idx])
+		e.Uint8(0x00)
+		e.Uint8(0xFF)
+		buf = buf[idx+1:]
+	}
+
+	// write terminator
+	e.Uint8(0x00)
+	e.Uint8(0x01)
+}
+
+// OrderedString reads a string written by the encoders OrderedString method.
+// If the string does not contain escaped bytes and is not cloned it may change
+// if the source byte slice changes. Otherwise, the string is unescaped into an
+// allocated buffer, using the arena if configured. Invalid escape sequences
+// result in ErrInvalidEscape.
+func (d *Decoder) OrderedString(clone bool) string {
+	// skip if errored
+	if d.err != nil {
+		return ""
+	}
+
+	// stitch following slices
+	d.need(d.Length())
+
+	// find end and count escapes
+	var end, escapes int
+	for {
+		// find zero byte
+		idx := bytes.IndexByte(d.buf[end:], 0)
+		if idx < 0 || end+idx+1 >= len(d.buf) {
+			d.fail(ErrBufferTooShort, "orderedstring", 0)
+			return ""
+		}
+		end += idx
+
+		// check escape
+		if d.buf[end+1] == 0x01 {
+			break
+		} else if d.buf[end+1] != 0xFF {
+			d.fail(ErrInvalidEscape, "orderedstring", 0)
+			return ""
+		}
+		escapes++
+		end += 2
+	}
+
+	// handle unescaped strings
+	if escapes == 0 {
+		str := d.string("orderedstring", end, clone)
+		d.Skip(2)
+		return str
+	}
+
+	// allocate buffer
+	var buf []byte
+	if d.arn != nil {
+		buf = d.arn.Get(end-escapes, false)
+	} else {
+		buf = make([]byte, end-escapes)
+	}
+
+	// unescape
+	var pos int
+	for i := 0; i < end; i++ {
+		buf[pos] = d.buf[i]
+		pos++
+		if d.buf[i] == 0 {
+			i++
+		}
+	}
+
+	// trace
+	if d.trc != nil {
+		d.trc("orderedstring", d.Offset(), end+2, toString(buf))
+	}
+
+	// slice
+	d.buf = d.buf[end+2:]
+
+	return toString(buf)
+}
+
+// OrderedInt16 writes a two byte signed integer that preserves the ordering of
+// the original numbers by flipping the sign bit. The number is always written
+// in big endian byte order.
+func (e *Encoder) OrderedInt16(num int16) {
+	e.Uint16BE(uint16(num) ^ 1<<15)
+}
+
+// OrderedInt32 writes a four byte signed integer that preserves the ordering
+// of the original numbers by flipping the sign bit. The number is always
+// written in big endian byte order.
+func (e *Encoder) OrderedInt32(num int32) {
+	e.Uint32BE(uint32(num) ^ 1<<31)
+}
+
+// OrderedInt64 writes an eight byte signed integer that preserves the ordering
+// of the original numbers by flipping the sign bit. The number is always
+// written in big endian byte order.
+func (e *Encoder) OrderedInt64(num int64) {
+	e.Uint64BE(uint64(num) ^ 1<<63)
+}
+
+// OrderedInt16 reads a two byte signed integer written by the encoders
+// OrderedInt16 method.
+func (d *Decoder) OrderedInt16() int16 {
+	return int16(d.uintBE("orderedint16", 2) ^ 1<<15)
+}
+
+// OrderedInt32 reads a four byte signed integer written by the encoders
+// OrderedInt32 method.
+func (d *Decoder) OrderedInt32() int32 {
+	return int32(d.uintBE("orderedint32", 4) ^ 1<<31)
+}
+
+// OrderedInt64 reads an eight byte signed integer written by the encoders
+// OrderedInt64 method.
+func (d *Decoder) OrderedInt64() int64 {
+	return int64(d.uintBE("orderedint64", 8) ^ 1<<63)
+}
+
+// OrderedFloat64 writes an eight byte float that preserves the ordering of the
+// original numbers. All bits of negative numbers and only the sign bit of
+// positive numbers are flipped. Therefore, negative zero sorts before positive
+// zero. NaN values are rejected with ErrNotANumber. The number is always
+// written in big endian byte order.
+func (e *Encoder) OrderedFloat64(num float64) {
+	// skip if errored
+	if e.err != nil {
+		return
+	}
+
+	// check number
+	if math.IsNaN(num) {
+		e.err = ErrNotANumber
+		return
+	}
+
+	// flip bits
+	bits := math.Float64bits(num)
+	if bits&(1<<63) != 0 {
+		bits = ^bits
+	} else {
+		bits |= 1 << 63
+	}
+
+	// write bits
+	e.Uint64BE(bits)
+}
+
+// OrderedFloat64 reads an eight byte float written by the encoders
+// OrderedFloat64 method. NaN values are rejected with ErrNotANumber.
+func (d *Decoder) OrderedFloat64() float64 {
+	// read bits
+	bits := d.uintBE("orderedfloat64", 8)
+	if d.err != nil {
+		return 0
+	}
+
+	// flip bits
+	if bits&(1<<63) != 0 {
+		bits ^= 1 << 63
+	} else {
+		bits = ^bits
+	}
+
+	// check number
+	num := math.Float64frombits(bits)
+	if math.IsNaN(num) {
+		d.failAt(ErrNotANumber, "orderedfloat64", d.Offset()-8, 8)
+		return 0
+	}
+
+	return num
+}
+
+// DescUint64 writes an eight byte unsigned integer with all bits inverted so
+// that the byte-wise ordering of the encoded numbers is descending. The number
+// is always written in big endian byte order.
+func (e *Encoder) DescUint64(num uint64) {
+	e.Uint64BE(^num)
+}
+
+// DescOrderedString writes an ordered string with all bytes inverted so that
+// the byte-wise ordering of the encoded strings is descending. Every 0x00 byte
+// is therefore escaped as 0xFF 0x00 and the string is terminated by 0xFF 0xFE.
+// As with the ascending encoding, no encoded string is a prefix of another.
+func (e *Encoder) DescOrderedString(str string) {
+	// skip if errored
+	if e.err != nil {
+		return
+	}
+
+	// determine length
+	num := len(str) + strings.Count(str, "\x00") + 2
+
+	// handle length
+	if e.buf == nil {
+		if e.rcd {
+			e.render(num, func(enc *Encoder) {
+				enc.DescOrderedString(str)
+			})
+		}
+		e.len += num
+		return
+	}
+
+	// grow buffer
+	if !e.grow("descorderedstring", num) {
+		return
+	}
+
+	// write inverted and escaped bytes
+	var pos int
+	for i := 0; i < len(str); i++ {
+		e.buf[pos] = ^str[i]
+		pos++
+		if str[i] == 0 {
+			e.buf[pos] = 0x00
+			pos++
+		}
+	}
+
+	// write inverted terminator
+	e.buf[pos] = 0xFF
+	e.buf[pos+1] = 0xFE
+
+	// trace
+	if e.trc != nil {
+		e.trc("descorderedstring", e.Offset(), num)
+	}
+
+	// slice
+	e.buf = e.buf[num:]
+}
+
+// DescUint64 reads an eight byte unsigned integer written by the encoders
+// DescUint64 method.
+func (d *Decoder) DescUint64() uint64 {
+	return ^d.uintBE("descuint64", 8)
+}
+
+// DescOrderedString reads a string written by the encoders DescOrderedString
+// method. The string is always decoded into an allocated buffer, using the
+// arena if configured. Invalid escape sequences result in ErrInvalidEscape.
+func (d *Decoder) DescOrderedString() string {
+	// skip if errored
+	if d.err != nil {
+		return ""
+	}
+
+	// stitch following slices
+	d.need(d.Length())
+
+	// find end and count escapes
+	var end, escapes int
+	for {
+		// find inverted zero byte
+		idx := bytes.IndexByte(d.buf[end:], 0xFF)
+		if idx < 0 || end+idx+1 >= len(d.buf) {
+			d.fail(ErrBufferTooShort, "descorderedstring", 0)
+			return ""
+		}
+		end += idx
+
+		// check escape
+		if d.buf[end+1] == 0xFE {
+			break
+		} else if d.buf[end+1] != 0x00 {
+			d.fail(ErrInvalidEscape, "descorderedstring", 0)
+			return ""
+		}
+		escapes++
+		end += 2
+	}
+
+	// allocate buffer
+	var buf []byte
+	if d.arn != nil {
+		buf = d.arn.Get(end-escapes, false)
+	} else {
+		buf = make([]byte, end-escapes)
+	}
+
+	// invert and unescape
+	var pos int
+	for i := 0; i < end; i++ {
+		buf[pos] = ^d.buf[i]
+		pos++
+		if d.buf[i] == 0xFF {
+			i++
+		}
+	}
+
+	// trace
+	if d.trc != nil {
+		d.trc("descorderedstring", d.Offset(), end+2, toString(buf))
+	}
+
+	// slice
+	d.buf = d.buf[end+2:]
+
+	return toString(buf)
+}
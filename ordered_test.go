@@ -0,0 +1,299 @@
+package fpack
+
+import (
+	"bytes"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderedString(t *testing.T) {
+	table := []struct {
+		str string
+		enc string
+	}{
+		{str: "", enc: "\x00\x01"},
+		{str: "foo", enc: "foo\x00\x01"},
+		{str: "\x00", enc: "\x00\xFF\x00\x01"},
+		{str: "a\x00b\x00", enc: "a\x00\xFFb\x00\xFF\x00\x01"},
+	}
+
+	for _, item := range table {
+		buf, _, err := Encode(nil, func(enc *Encoder) error {
+			enc.OrderedString(item.str)
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, item.enc, string(buf))
+
+		var str1, str2 string
+		err = Decode(append(buf, buf...), func(dec *Decoder) error {
+			str1 = dec.OrderedString(false)
+			str2 = dec.OrderedString(true)
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, item.str, str1)
+		assert.Equal(t, item.str, str2)
+	}
+
+	for _, data := range []string{"", "foo", "foo\x00", "foo\x00\xFF"} {
+		err := Decode([]byte(data), func(dec *Decoder) error {
+			dec.OrderedString(false)
+			return nil
+		})
+		assert.ErrorIs(t, err, ErrBufferTooShort, data)
+	}
+
+	err := Decode([]byte("foo\x00\x02"), func(dec *Decoder) error {
+		dec.OrderedString(false)
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrInvalidEscape)
+}
+
+func TestOrderedStringSorting(t *testing.T) {
+	strs := []string{"", "\x00", "\x00\x00", "\x01", "a", "a\x00", "a\x00b", "a\x01", "ab", "b", "\xFF"}
+
+	var keys [][]byte
+	for _, str := range strs {
+		buf, _, err := Encode(nil, func(enc *Encoder) error {
+			enc.OrderedString(str)
+			enc.Uint8(0xFF)
+			return nil
+		})
+		assert.NoError(t, err)
+		keys = append(keys, buf)
+	}
+
+	assert.True(t, sort.SliceIsSorted(keys, func(i, j int) bool {
+		return bytes.Compare(keys[i], keys[j]) < 0
+	}))
+}
+
+func TestOrderedInt(t *testing.T) {
+	nums := []int64{math.MinInt64, math.MinInt32, -1, 0, 1, math.MaxInt32, math.MaxInt64}
+
+	for _, num := range nums {
+		buf, _, err := Encode(nil, func(enc *Encoder) error {
+			enc.UseLittleEndian()
+			enc.OrderedInt64(num)
+			enc.OrderedInt32(int32(num))
+			enc.OrderedInt16(int16(num))
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Len(t, buf, 14)
+
+		var i64 int64
+		var i32 int32
+		var i16 int16
+		err = Decode(buf, func(dec *Decoder) error {
+			dec.UseLittleEndian()
+			i64 = dec.OrderedInt64()
+			i32 = dec.OrderedInt32()
+			i16 = dec.OrderedInt16()
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, num, i64)
+		assert.Equal(t, int32(num), i32)
+		assert.Equal(t, int16(num), i16)
+	}
+
+	buf, _, err := Encode(nil, func(enc *Encoder) error {
+		enc.OrderedInt16(-2)
+		enc.OrderedInt16(1)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x7F, 0xFE, 0x80, 0x01}, buf)
+}
+
+func TestOrderedIntSorting(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 1000; i++ {
+		a := int64(rng.Uint64())
+		b := int64(rng.Uint64())
+
+		bufA, _, err := Encode(nil, func(enc *Encoder) error {
+			enc.OrderedInt64(a)
+			return nil
+		})
+		assert.NoError(t, err)
+
+		bufB, _, err := Encode(nil, func(enc *Encoder) error {
+			enc.OrderedInt64(b)
+			return nil
+		})
+		assert.NoError(t, err)
+
+		assert.Equal(t, a < b, bytes.Compare(bufA, bufB) < 0, "%d %d", a, b)
+	}
+}
+
+func TestOrderedFloat64(t *testing.T) {
+	nums := []float64{
+		math.Inf(-1),
+		-math.MaxFloat64,
+		-1,
+		-math.SmallestNonzeroFloat64,
+		math.Copysign(0, -1),
+		0,
+		math.SmallestNonzeroFloat64,
+		1,
+		math.MaxFloat64,
+		math.Inf(1),
+	}
+
+	var keys [][]byte
+	for _, num := range nums {
+		buf, _, err := Encode(nil, func(enc *Encoder) error {
+			enc.OrderedFloat64(num)
+			return nil
+		})
+		assert.NoError(t, err)
+		keys = append(keys, buf)
+
+		var res float64
+		err = Decode(buf, func(dec *Decoder) error {
+			res = dec.OrderedFloat64()
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, math.Float64bits(num), math.Float64bits(res))
+	}
+
+	assert.True(t, sort.SliceIsSorted(keys, func(i, j int) bool {
+		return bytes.Compare(keys[i], keys[j]) < 0
+	}))
+
+	_, _, err := Encode(nil, func(enc *Encoder) error {
+		enc.OrderedFloat64(math.NaN())
+		return nil
+	})
+	assert.Equal(t, ErrNotANumber, err)
+
+	err = Decode([]byte{0xFF, 0xF8, 0, 0, 0, 0, 0, 1}, func(dec *Decoder) error {
+		dec.OrderedFloat64()
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrNotANumber)
+}
+
+func TestOrderedFloat64Sorting(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 1000; i++ {
+		a := (rng.Float64() - 0.5) * math.Pow(10, float64(rng.Intn(40)-20))
+		b := (rng.Float64() - 0.5) * math.Pow(10, float64(rng.Intn(40)-20))
+
+		bufA, _, err := Encode(nil, func(enc *Encoder) error {
+			enc.OrderedFloat64(a)
+			return nil
+		})
+		assert.NoError(t, err)
+
+		bufB, _, err := Encode(nil, func(enc *Encoder) error {
+			enc.OrderedFloat64(b)
+			return nil
+		})
+		assert.NoError(t, err)
+
+		assert.Equal(t, a < b, bytes.Compare(bufA, bufB) < 0, "%f %f", a, b)
+	}
+}
+
+func TestDescending(t *testing.T) {
+	buf, _, err := Encode(nil, func(enc *Encoder) error {
+		enc.DescUint64(1)
+		enc.DescOrderedString("a\x00b")
+		enc.DescOrderedString("")
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "\xFF\xFF\xFF\xFF\xFF\xFF\xFF\xFE"+
+		"\x9E\xFF\x00\x9D\xFF\xFE"+
+		"\xFF\xFE", string(buf))
+
+	var num uint64
+	var str1, str2 string
+	err = Decode(buf, func(dec *Decoder) error {
+		num = dec.DescUint64()
+		str1 = dec.DescOrderedString()
+		str2 = dec.DescOrderedString()
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), num)
+	assert.Equal(t, "a\x00b", str1)
+	assert.Equal(t, "", str2)
+
+	err = Decode([]byte("\x9E\xFF\x01"), func(dec *Decoder) error {
+		dec.DescOrderedString()
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrInvalidEscape)
+
+	err = Decode([]byte("\x9E\xFF"), func(dec *Decoder) error {
+		dec.DescOrderedString()
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrBufferTooShort)
+}
+
+func TestDescendingDynamic(t *testing.T) {
+	str := strings.Repeat("a\x00b", dynamicSize)
+
+	expected, _, err := Encode(nil, func(enc *Encoder) error {
+		enc.Uint8(7)
+		enc.DescOrderedString(str)
+		return nil
+	})
+	assert.NoError(t, err)
+
+	buf, ref, err := EncodeDynamic(nil, func(enc *Encoder) error {
+		enc.Uint8(7)
+		enc.DescOrderedString(str)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, expected, buf)
+	ref.Release()
+
+	var out string
+	err = Decode(expected, func(dec *Decoder) error {
+		dec.Uint8()
+		out = dec.DescOrderedString()
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, str, out)
+}
+
+func TestDescendingSorting(t *testing.T) {
+	strs := []string{"\xFF", "b", "ab", "a\x01", "a\x00b", "a\x00", "a", "\x01", "\x00\x00", "\x00", ""}
+	nums := []uint64{math.MaxUint64, 42, 1, 0}
+
+	var keys [][]byte
+	for _, str := range strs {
+		for _, num := range nums {
+			buf, _, err := Encode(nil, func(enc *Encoder) error {
+				enc.DescOrderedString(str)
+				enc.DescUint64(num)
+				return nil
+			})
+			assert.NoError(t, err)
+			keys = append(keys, buf)
+		}
+	}
+
+	assert.True(t, sort.SliceIsSorted(keys, func(i, j int) bool {
+		return bytes.Compare(keys[i], keys[j]) < 0
+	}))
+}
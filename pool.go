@@ -6,56 +6,401 @@ import (
 	"runtime/debug"
 	"sync"
 	"sync/atomic"
+	"time"
+	"unsafe"
 )
 
-var global = NewPool()
+var global atomic.Value
+
+func init() {
+	global.Store(NewPool())
+}
 
 // Global returns the global pool.
 func Global() *Pool {
-	return global
+	return global.Load().(*Pool)
 }
 
-var tracker func([]byte)
+// SetGlobal will replace the global pool with the provided pool. References
+// borrowed from the previous pool remain valid and are released against it.
+// Track must be called again after replacing the global pool.
+func SetGlobal(p *Pool) {
+	// check pool
+	if p == nil {
+		panic("fpack: nil pool")
+	}
 
-// Track will enable buffer tracking if a function is provided and disable it
-// otherwise. The registered function will receive stack traces for leaked
-// buffers.
+	global.Store(p)
+}
+
+// Track will enable buffer tracking on the global pool. See Pool.Track for
+// details.
 func Track(fn func([]byte)) {
-	tracker = fn
+	Global().Track(fn)
 }
 
 type buffer struct {
 	gen   uint64
+	refs  int32
 	pool  int8
+	owner *Pool
 	slice []byte
 	stack []byte
+	len   int
+	time  time.Time
 }
 
 // Pool is dynamic slice length pool.
 type Pool struct {
-	gen   uint64
-	pools []*sync.Pool
+	gen     uint64
+	pools   []*sync.Pool
+	tracker atomic.Value
+	idle    *idleList
+	stats   *poolStats
+	wipe    bool
+	poison  bool
+	misuse  func()
+	events  func(Event)
+}
+
+// idleClass is the first class that is pooled using the idle list if a
+// maximum of idle bytes is set.
+const idleClass = 10
+
+type idleList struct {
+	mutex sync.Mutex
+	max   int
+	size  int
+	lists [16][]*buffer
+}
+
+type tracker struct {
+	fn func(LeakReport)
+}
+
+// LeakReport describes a leaked buffer.
+type LeakReport struct {
+	// Length is the requested length.
+	Length int
+
+	// Size is the size of the buffer class.
+	Size int
+
+	// Borrowed is the time the buffer has been borrowed.
+	Borrowed time.Time
+
+	// Stack is the stack trace of the borrow.
+	Stack []byte
+}
+
+type poolStats struct {
+	classes [16]classCounters
+	small   uint64
+	large   uint64
+}
+
+type classCounters struct {
+	borrows uint64
+	returns uint64
+	news    uint64
+	drops   uint64
 }
 
 // NewPool creates and returns a new pool.
 func NewPool() *Pool {
+	// prepare pool
+	p := &Pool{}
+
 	// create 16 pools from 1 KB to 32 MB
-	var pools []*sync.Pool
 	for i := 0; i < 16; i++ {
 		num := int8(i)
 		size := 1 << (i + 10)
-		pools = append(pools, &sync.Pool{
+		p.pools = append(p.pools, &sync.Pool{
 			New: func() interface{} {
+				if p.stats != nil {
+					atomic.AddUint64(&p.stats.classes[num].news, 1)
+				}
 				return &buffer{
 					pool:  num,
+					owner: p,
 					slice: make([]byte, size),
 				}
 			},
 		})
 	}
 
-	return &Pool{
-		pools: pools,
+	return p
+}
+
+// Track will enable buffer tracking if a function is provided and disable it
+// otherwise. The registered function will receive stack traces for leaked
+// buffers of this pool. It may be called while the pool is used.
+func (p *Pool) Track(fn func([]byte)) {
+	// check function
+	if fn == nil {
+		p.TrackDetailed(nil)
+		return
+	}
+
+	p.TrackDetailed(func(report LeakReport) {
+		fn(report.Stack)
+	})
+}
+
+// TrackDetailed is like Track but the registered function will receive a
+// report for leaked buffers that also includes the requested length, class
+// size and time of the borrow.
+func (p *Pool) TrackDetailed(fn func(LeakReport)) {
+	p.tracker.Store(tracker{fn: fn})
+}
+
+func (p *Pool) track() func(LeakReport) {
+	t, _ := p.tracker.Load().(tracker)
+	return t.fn
+}
+
+// SetWipe will enable or disable zeroing of released buffers. If enabled, the
+// full underlying buffer is zeroed when released, including bytes beyond the
+// borrowed length, to not keep sensitive data in memory. It should be called
+// before the pool is used.
+func (p *Pool) SetWipe(wipe bool) {
+	p.wipe = wipe
+}
+
+// EventType is the type of pool event.
+type EventType int
+
+// The available pool event types.
+const (
+	// BorrowEvent is emitted when a buffer is borrowed.
+	BorrowEvent EventType = iota
+
+	// ReleaseEvent is emitted when a buffer is recycled.
+	ReleaseEvent
+
+	// AllocateEvent is emitted when a slice is allocated because the requested
+	// length is too small or too big to be pooled.
+	AllocateEvent
+
+	// LeakEvent is emitted when a leaked buffer is detected. Leaks are only
+	// detected if tracking is enabled.
+	LeakEvent
+)
+
+// String returns the name of the event type.
+func (t EventType) String() string {
+	switch t {
+	case BorrowEvent:
+		return "borrow"
+	case ReleaseEvent:
+		return "release"
+	case AllocateEvent:
+		return "allocate"
+	case LeakEvent:
+		return "leak"
+	default:
+		return "unknown"
+	}
+}
+
+// Event describes a pool event.
+type Event struct {
+	// Type is the type of the event.
+	Type EventType
+
+	// Size is the size of the buffer class or zero for allocations.
+	Size int
+
+	// Length is the requested length.
+	Length int
+}
+
+// OnEvent will register a function that is called synchronously for every
+// pool event. It should be called before the pool is used.
+func (p *Pool) OnEvent(fn func(Event)) {
+	p.events = fn
+}
+
+// OnMisuse will register a function that is called instead of panicking if a
+// reference is released or retained after it has been released. The buffer is
+// not recycled again in this case. The function is called synchronously and
+// may capture the stack trace using debug.Stack(). It should be called before
+// the pool is used.
+func (p *Pool) OnMisuse(fn func()) {
+	p.misuse = fn
+}
+
+func (p *Pool) mismatch() {
+	// panic if strict
+	if p.misuse == nil {
+		panic("fpack: generation mismatch")
+	}
+
+	// report misuse
+	p.misuse()
+}
+
+// Poison is the byte used to fill released buffers if poisoning is enabled.
+const Poison = 0xDD
+
+// SetPoison will enable or disable poisoning of released buffers. If enabled,
+// the requested length of a released buffer is filled with the Poison byte to
+// make reads after release easy to detect. It is meant for debugging and
+// should be called before the pool is used.
+func (p *Pool) SetPoison(poison bool) {
+	p.poison = poison
+}
+
+// SetMaxIdleBytes will set the maximum amount of bytes held by idle buffers of
+// 1 MiB and above. Released buffers that would exceed the maximum are dropped.
+// These buffers are kept in a bounded list instead of a sync.Pool while
+// smaller buffers are unaffected. A zero or negative maximum disables the
+// limit. It should be called before the pool is used.
+func (p *Pool) SetMaxIdleBytes(max int) {
+	if max > 0 {
+		p.idle = &idleList{max: max}
+	} else {
+		p.idle = nil
+	}
+}
+
+// Warm will preallocate the specified count of buffers in the class used for
+// slices of the specified length. The buffers are only kept until the next
+// garbage collection, unless a maximum of idle bytes is set and the class is
+// kept in the bounded idle list. Lengths that are not pooled are ignored.
+func (p *Pool) Warm(len, count int) {
+	// determine pool
+	pool := bits.Len64(uint64(len)) - 10
+	if pool < 0 {
+		pool = 0
+	}
+
+	// check length
+	if len < 9 || pool >= 16 {
+		return
+	}
+
+	// add buffers
+	for i := 0; i < count; i++ {
+		p.put(p.pools[pool].New().(*buffer))
+	}
+}
+
+func (p *Pool) get(pool int) *buffer {
+	// use sync pool if not limited
+	if p.idle == nil || pool < idleClass {
+		return p.pools[pool].Get().(*buffer)
+	}
+
+	// take idle buffer
+	p.idle.mutex.Lock()
+	list := p.idle.lists[pool]
+	if len(list) > 0 {
+		buf := list[len(list)-1]
+		list[len(list)-1] = nil
+		p.idle.lists[pool] = list[:len(list)-1]
+		p.idle.size -= len(buf.slice)
+		p.idle.mutex.Unlock()
+		return buf
+	}
+	p.idle.mutex.Unlock()
+
+	return p.pools[pool].New().(*buffer)
+}
+
+func (p *Pool) put(buf *buffer) {
+	// use sync pool if not limited
+	if p.idle == nil || buf.pool < idleClass {
+		p.pools[buf.pool].Put(buf)
+		return
+	}
+
+	// keep buffer if below maximum
+	p.idle.mutex.Lock()
+	if p.idle.size+len(buf.slice) <= p.idle.max {
+		p.idle.lists[buf.pool] = append(p.idle.lists[buf.pool], buf)
+		p.idle.size += len(buf.slice)
+		p.idle.mutex.Unlock()
+		return
+	}
+	p.idle.mutex.Unlock()
+
+	// count drop
+	if p.stats != nil {
+		atomic.AddUint64(&p.stats.classes[buf.pool].drops, 1)
+	}
+}
+
+// ClassStats holds the statistics of a single pool class.
+type ClassStats struct {
+	// Size is the size of the buffers in the class.
+	Size int
+
+	// Borrows is the number of borrowed buffers.
+	Borrows uint64
+
+	// Returns is the number of released buffers.
+	Returns uint64
+
+	// News is the number of buffers allocated because the class was empty.
+	News uint64
+
+	// Drops is the number of released buffers that have been dropped due to
+	// the maximum of idle bytes.
+	Drops uint64
+
+	// Outstanding is the number of currently borrowed buffers.
+	Outstanding int64
+}
+
+// PoolStats holds the statistics of a pool.
+type PoolStats struct {
+	// Classes are the statistics of each class ordered by size.
+	Classes []ClassStats
+
+	// Small is the number of allocated slices of up to 8 bytes.
+	Small uint64
+
+	// Large is the number of allocated slices above 32 MiB.
+	Large uint64
+}
+
+// EnableStats will enable the collection of statistics. It should be called
+// before the pool is used.
+func (p *Pool) EnableStats() {
+	if p.stats == nil {
+		p.stats = &poolStats{}
+	}
+}
+
+// Stats returns the collected statistics. It returns an empty value if
+// statistics have not been enabled.
+func (p *Pool) Stats() PoolStats {
+	// check stats
+	if p.stats == nil {
+		return PoolStats{}
+	}
+
+	// collect classes
+	classes := make([]ClassStats, 0, len(p.stats.classes))
+	for i := range p.stats.classes {
+		c := &p.stats.classes[i]
+		borrows := atomic.LoadUint64(&c.borrows)
+		returns := atomic.LoadUint64(&c.returns)
+		classes = append(classes, ClassStats{
+			Size:        1 << (i + 10),
+			Borrows:     borrows,
+			Returns:     returns,
+			News:        atomic.LoadUint64(&c.news),
+			Drops:       atomic.LoadUint64(&c.drops),
+			Outstanding: int64(borrows - returns),
+		})
+	}
+
+	return PoolStats{
+		Classes: classes,
+		Small:   atomic.LoadUint64(&p.stats.small),
+		Large:   atomic.LoadUint64(&p.stats.large),
 	}
 }
 
@@ -69,27 +414,139 @@ type Ref struct {
 	buf  *buffer
 }
 
+// Retain will add a holder to the borrowed slice and return the reference. The
+// slice is only recycled once the reference has been released by every holder.
+// Retaining a zero reference is a no-op and retaining a released reference
+// will panic unless a misuse function is registered.
+func (r Ref) Retain() Ref {
+	// treat zero refs as no-ops
+	if r == zeroRef {
+		return r
+	}
+
+	// check generation
+	if atomic.LoadUint64(&r.buf.gen) != r.gen {
+		r.pool.mismatch()
+		return r
+	}
+
+	// increment holders
+	atomic.AddInt32(&r.buf.refs, 1)
+
+	return r
+}
+
 // Release will release the borrowed slice. The function should be called at
-// most once and will panic otherwise.
+// most once per holder and will panic otherwise, unless a misuse function is
+// registered.
 func (r Ref) Release() {
+	r.release()
+}
+
+// Close will release the borrowed slice and implements the io.Closer interface.
+// Like Release, it will panic if called more than once per holder, unless a
+// misuse function is registered, in which case ErrGenerationMismatch is
+// returned.
+func (r Ref) Close() error {
+	if !r.release() {
+		return ErrGenerationMismatch
+	}
+	return nil
+}
+
+// Clone will copy the provided slice, which should be a portion of the
+// referenced slice, into a slice borrowed from the provided pool or the pool
+// of the reference if nil. The copy has its own reference and the original may
+// be released right away. Cloning from a released reference will panic unless
+// a misuse function is registered, in which case nil and a zero reference are
+// returned.
+func (r Ref) Clone(pool *Pool, slice []byte) ([]byte, Ref) {
+	// check reference
+	if r != zeroRef {
+		if atomic.LoadUint64(&r.buf.gen) != r.gen {
+			r.pool.mismatch()
+			return nil, Ref{}
+		}
+	}
+
+	// select pool
+	if pool == nil {
+		pool = r.pool
+	}
+	if pool == nil {
+		pool = Global()
+	}
+
+	return pool.Clone(slice)
+}
+
+func (r Ref) release() bool {
 	// treat zero refs as no-ops
 	if r == zeroRef {
-		return
+		return true
+	}
+
+	// check generation
+	if atomic.LoadUint64(&r.buf.gen) != r.gen {
+		r.pool.mismatch()
+		return false
+	}
+
+	// decrement holders
+	refs := atomic.AddInt32(&r.buf.refs, -1)
+	if refs > 0 {
+		return true
+	} else if refs < 0 {
+		r.pool.mismatch()
+		return false
 	}
 
 	// reset and check generation
 	if !atomic.CompareAndSwapUint64(&r.buf.gen, r.gen, 0) {
-		panic("fpack: generation mismatch")
+		r.pool.mismatch()
+		return false
 	}
 
 	// clear finalizer if tracked
-	if tracker != nil {
+	if r.buf.stack != nil {
 		r.buf.stack = nil
 		runtime.SetFinalizer(r.buf, nil)
 	}
 
+	// wipe buffer if requested
+	if r.pool.wipe {
+		for i := range r.buf.slice {
+			r.buf.slice[i] = 0
+		}
+	}
+
+	// poison buffer if requested
+	if r.pool.poison && r.buf.len > 0 {
+		slice := r.buf.slice[:r.buf.len]
+		slice[0] = Poison
+		for i := 1; i < len(slice); i *= 2 {
+			copy(slice[i:], slice[:i])
+		}
+	}
+
+	// count return
+	if r.pool.stats != nil {
+		atomic.AddUint64(&r.pool.stats.classes[r.buf.pool].returns, 1)
+	}
+
+	// emit event
+	if r.pool.events != nil {
+		r.pool.events(Event{
+			Type:   ReleaseEvent,
+			Size:   len(r.buf.slice),
+			Length: r.buf.len,
+		})
+	}
+
 	// recycle buffer
-	r.pool.pools[r.buf.pool].Put(r.buf)
+	r.pool.put(r.buf)
+
+	return true
 }
 
 // Borrow will return a slice that has the specified length. If the requested
@@ -105,8 +562,21 @@ func (r Ref) Release() {
 // using the Go allocator to ensure not used memory is available to be freed
 // immediately if not used anymore.
 func (p *Pool) Borrow(len int, zero bool) ([]byte, Ref) {
+	return p.BorrowCap(len, len, zero)
+}
+
+// BorrowCap is like Borrow but will select the buffer based on the specified
+// capacity. The returned slice has the specified length and a capacity of at
+// least the specified capacity, which may be used to append to the slice. A
+// capacity below the length is ignored.
+func (p *Pool) BorrowCap(len, cap int, zero bool) ([]byte, Ref) {
+	// ensure capacity
+	if cap < len {
+		cap = len
+	}
+
 	// determine pool
-	pool := bits.Len64(uint64(len)) - 10
+	pool := bits.Len64(uint64(cap)) - 10
 	if pool < 0 {
 		pool = 0
 	} else if pool >= 16 {
@@ -114,8 +584,21 @@ func (p *Pool) Borrow(len int, zero bool) ([]byte, Ref) {
 	}
 
 	// allocate if too small or too big
-	if len < 9 || pool == -1 {
-		return make([]byte, len), Ref{}
+	if cap < 9 || pool == -1 {
+		if p.stats != nil {
+			if pool == -1 {
+				atomic.AddUint64(&p.stats.large, 1)
+			} else {
+				atomic.AddUint64(&p.stats.small, 1)
+			}
+		}
+		if p.events != nil {
+			p.events(Event{
+				Type:   AllocateEvent,
+				Length: len,
+			})
+		}
+		return make([]byte, len, cap), Ref{}
 	}
 
 	// get next non zero generation
@@ -125,10 +608,26 @@ func (p *Pool) Borrow(len int, zero bool) ([]byte, Ref) {
 	}
 
 	// get from pool
-	buf := p.pools[pool].Get().(*buffer)
+	buf := p.get(pool)
 
-	// set generation
+	// set generation, holders and length
 	buf.gen = gen
+	buf.refs = 1
+	buf.len = len
+
+	// count borrow
+	if p.stats != nil {
+		atomic.AddUint64(&p.stats.classes[pool].borrows, 1)
+	}
+
+	// emit event
+	if p.events != nil {
+		p.events(Event{
+			Type:   BorrowEvent,
+			Size:   1 << (pool + 10),
+			Length: len,
+		})
+	}
 
 	// prepare slice
 	slice := buf.slice[0:len]
@@ -141,8 +640,9 @@ func (p *Pool) Borrow(len int, zero bool) ([]byte, Ref) {
 	}
 
 	// add finalizer if tracked
-	if tracker != nil {
+	if p.track() != nil {
 		buf.stack = debug.Stack()
+		buf.time = time.Now()
 		runtime.SetFinalizer(buf, finalizer)
 	}
 
@@ -156,7 +656,42 @@ func (p *Pool) Borrow(len int, zero bool) ([]byte, Ref) {
 	return slice, ref
 }
 
-// Clone will copy the provided slice into a borrowed slice.
+// BorrowAligned is like Borrow but the first byte of the returned slice will be
+// aligned to the specified alignment, e.g. 4096 for direct I/O. The alignment
+// must be a power of two and the function will panic otherwise. The buffer is
+// selected with enough headroom to align the slice.
+func (p *Pool) BorrowAligned(len, align int, zero bool) ([]byte, Ref) {
+	// check alignment
+	if align <= 0 || align&(align-1) != 0 {
+		panic("fpack: invalid alignment")
+	}
+
+	// borrow buffer with headroom
+	buf, ref := p.Borrow(len+align-1, false)
+	if len == 0 {
+		return buf[:0], ref
+	}
+
+	// determine offset
+	addr := uintptr(unsafe.Pointer(&buf[0]))
+	offset := int(-addr & uintptr(align-1))
+
+	// prepare slice
+	slice := buf[offset : offset+len]
+
+	// zero slice if requested
+	if zero {
+		for i := range slice {
+			slice[i] = 0
+		}
+	}
+
+	return slice, ref
+}
+
+// Clone will copy the provided slice into a borrowed slice. The copy has its
+// own reference and is independent of the buffer the provided slice may have
+// been borrowed from, which can therefore be released right away.
 func (p *Pool) Clone(slice []byte) ([]byte, Ref) {
 	// borrow buffer
 	buf, ref := p.Borrow(len(slice), false)
@@ -167,6 +702,29 @@ func (p *Pool) Clone(slice []byte) ([]byte, Ref) {
 	return buf, ref
 }
 
+// Resize will return a slice with the specified length that starts with the
+// contents of the provided borrowed slice. If the length fits the capacity of
+// the slice, it is re-sliced and returned with the same reference. Otherwise,
+// a larger slice is borrowed, the contents are copied and the provided
+// reference is released. Bytes beyond the previous length are not zeroed.
+func (p *Pool) Resize(buf []byte, ref Ref, len int) ([]byte, Ref) {
+	// re-slice if possible
+	if len <= cap(buf) {
+		return buf[:len], ref
+	}
+
+	// borrow larger buffer
+	newBuf, newRef := p.Borrow(len, false)
+
+	// copy bytes
+	copy(newBuf, buf)
+
+	// release old buffer
+	ref.Release()
+
+	return newBuf, newRef
+}
+
 // Concat will concatenate the provided byte slices using a borrowed slice.
 func (p *Pool) Concat(slices ...[]byte) ([]byte, Ref) {
 	// compute total length
@@ -189,6 +747,20 @@ func (p *Pool) Concat(slices ...[]byte) ([]byte, Ref) {
 
 func finalizer(buf *buffer) {
 	if buf.gen != 0 {
-		tracker(buf.stack)
+		if buf.owner.events != nil {
+			buf.owner.events(Event{
+				Type:   LeakEvent,
+				Size:   len(buf.slice),
+				Length: buf.len,
+			})
+		}
+		if fn := buf.owner.track(); fn != nil {
+			fn(LeakReport{
+				Length:   buf.len,
+				Size:     len(buf.slice),
+				Borrowed: buf.time,
+				Stack:    buf.stack,
+			})
+		}
 	}
 }
@@ -1,10 +1,17 @@
 package fpack
 
 import (
+	"bytes"
+	"expvar"
+	"fmt"
+	"io"
 	"math"
 	"runtime"
 	"strconv"
+	"sync"
 	"testing"
+	"time"
+	"unsafe"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -15,6 +22,33 @@ func TestGlobal(t *testing.T) {
 	assert.NotNil(t, Global())
 }
 
+func TestSetGlobal(t *testing.T) {
+	old := Global()
+
+	pool := NewPool()
+	pool.EnableStats()
+
+	buf1, ref1 := Global().Borrow(123, false)
+	SetGlobal(pool)
+	assert.Equal(t, pool, Global())
+
+	buf2, ref2 := Global().Borrow(123, false)
+	assert.Equal(t, uint64(1), pool.Stats().Classes[0].Borrows)
+	assert.Equal(t, 123, len(buf1))
+	assert.Equal(t, 123, len(buf2))
+
+	ref1.Release()
+	ref2.Release()
+	assert.Equal(t, uint64(1), pool.Stats().Classes[0].Returns)
+
+	assert.PanicsWithValue(t, "fpack: nil pool", func() {
+		SetGlobal(nil)
+	})
+
+	SetGlobal(old)
+	assert.Equal(t, old, Global())
+}
+
 func TestNoop(t *testing.T) {
 	assert.NotPanics(t, func() {
 		Ref{}.Release()
@@ -58,6 +92,57 @@ func TestBorrowCapacity(t *testing.T) {
 	ref.Release()
 }
 
+func TestBorrowCap(t *testing.T) {
+	buf, ref := Global().BorrowCap(4, 7, false)
+	assert.Equal(t, 4, len(buf))
+	assert.Equal(t, 7, cap(buf))
+	ref.Release()
+
+	buf, ref = Global().BorrowCap(100, 3000, true)
+	assert.Equal(t, 100, len(buf))
+	assert.Equal(t, 4096, cap(buf))
+	assert.Equal(t, make([]byte, 100), buf)
+
+	buf = append(buf, make([]byte, 2900)...)
+	assert.Equal(t, 3000, len(buf))
+	assert.Equal(t, 4096, cap(buf))
+	ref.Release()
+
+	buf, ref = Global().BorrowCap(2000, 100, false)
+	assert.Equal(t, 2000, len(buf))
+	assert.Equal(t, 2048, cap(buf))
+	ref.Release()
+
+	buf, ref = Global().BorrowCap(10, 777<<17, false)
+	assert.Equal(t, 10, len(buf))
+	assert.Equal(t, 777<<17, cap(buf))
+	ref.Release()
+}
+
+func TestBorrowAligned(t *testing.T) {
+	for _, align := range []int{1, 8, 512, 4096} {
+		for i := 0; i < 200; i++ {
+			length := (i * 997) % (128 << 10)
+			buf, ref := Global().BorrowAligned(length, align, i%2 == 0)
+			assert.Equal(t, length, len(buf))
+			if length > 0 {
+				addr := uintptr(unsafe.Pointer(&buf[0]))
+				assert.Equal(t, uintptr(0), addr%uintptr(align))
+			}
+			if i%2 == 0 {
+				assert.Equal(t, make([]byte, length), buf)
+			}
+			ref.Release()
+		}
+	}
+
+	for _, align := range []int{-1, 0, 3, 4097} {
+		assert.PanicsWithValue(t, "fpack: invalid alignment", func() {
+			Global().BorrowAligned(10, align, false)
+		})
+	}
+}
+
 func TestDoubleRelease(t *testing.T) {
 	runtime.GC()
 
@@ -78,6 +163,34 @@ func TestDoubleRelease(t *testing.T) {
 	})
 }
 
+func TestMisuse(t *testing.T) {
+	var misuses int
+	pool := NewPool()
+	pool.EnableStats()
+	pool.OnMisuse(func() {
+		misuses++
+	})
+
+	_, ref1 := pool.Borrow(123, false)
+	ref1.Release()
+
+	ref1.Release()
+	assert.Equal(t, 1, misuses)
+
+	ref1.Retain()
+	assert.Equal(t, 2, misuses)
+
+	_, ref2 := pool.Borrow(123, false)
+	assert.NotEqual(t, ref1, ref2)
+
+	ref1.Release()
+	assert.Equal(t, 3, misuses)
+
+	ref2.Release()
+	assert.Equal(t, 3, misuses)
+	assert.Equal(t, uint64(2), pool.Stats().Classes[0].Returns)
+}
+
 func TestLeakedBuffer(t *testing.T) {
 	runtime.GC()
 
@@ -98,8 +211,291 @@ func TestLeakedBuffer(t *testing.T) {
 	assert.Empty(t, stack)
 }
 
+func TestPoolTrack(t *testing.T) {
+	runtime.GC()
+
+	var mutex sync.Mutex
+	var stack []byte
+	leaked := func() []byte {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return stack
+	}
+
+	pool := NewPool()
+	pool.Track(func(bytes []byte) {
+		mutex.Lock()
+		stack = bytes
+		mutex.Unlock()
+	})
+
+	_, _ = Global().Borrow(123, false)
+	runtime.GC()
+	assert.Empty(t, leaked())
+
+	_, _ = pool.Borrow(123, false)
+	runtime.GC()
+	assert.Eventually(t, func() bool {
+		return len(leaked()) > 0
+	}, time.Second, time.Millisecond)
+
+	pool.Track(nil)
+	mutex.Lock()
+	stack = nil
+	mutex.Unlock()
+
+	_, _ = pool.Borrow(123, false)
+	runtime.GC()
+	assert.Empty(t, leaked())
+}
+
+func TestPoolTrackDetailed(t *testing.T) {
+	runtime.GC()
+
+	reports := make(chan LeakReport, 1)
+	pool := NewPool()
+	pool.TrackDetailed(func(report LeakReport) {
+		reports <- report
+	})
+
+	start := time.Now()
+	_, _ = pool.Borrow(1500, false)
+	runtime.GC()
+
+	select {
+	case report := <-reports:
+		assert.Equal(t, 1500, report.Length)
+		assert.Equal(t, 2048, report.Size)
+		assert.False(t, report.Borrowed.Before(start))
+		assert.NotEmpty(t, report.Stack)
+	case <-time.After(time.Second):
+		assert.Fail(t, "missing report")
+	}
+
+	pool.TrackDetailed(nil)
+}
+
+func TestPoolEvents(t *testing.T) {
+	runtime.GC()
+
+	var mutex sync.Mutex
+	var events []Event
+	pool := NewPool()
+	pool.OnEvent(func(event Event) {
+		mutex.Lock()
+		events = append(events, event)
+		mutex.Unlock()
+	})
+
+	_, ref := pool.Borrow(1500, false)
+	ref.Release()
+	pool.Borrow(5, false)
+	pool.Borrow(777<<17, false)
+
+	pool.Track(func([]byte) {})
+	_, _ = pool.Borrow(123, false)
+	runtime.GC()
+
+	assert.Eventually(t, func() bool {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return len(events) == 6
+	}, time.Second, time.Millisecond)
+
+	mutex.Lock()
+	assert.Equal(t, []Event{
+		{Type: BorrowEvent, Size: 2048, Length: 1500},
+		{Type: ReleaseEvent, Size: 2048, Length: 1500},
+		{Type: AllocateEvent, Length: 5},
+		{Type: AllocateEvent, Length: 777 << 17},
+		{Type: BorrowEvent, Size: 1024, Length: 123},
+		{Type: LeakEvent, Size: 1024, Length: 123},
+	}, events)
+	mutex.Unlock()
+
+	pool.Track(nil)
+}
+
+func ExamplePool_OnEvent() {
+	// prepare counters, publish using expvar.Publish()
+	counters := new(expvar.Map).Init()
+
+	// create pool
+	pool := NewPool()
+	pool.OnEvent(func(event Event) {
+		counters.Add(event.Type.String(), 1)
+		counters.Add(event.Type.String()+"_bytes", int64(event.Length))
+	})
+
+	// use pool
+	_, ref := pool.Borrow(123, false)
+	ref.Release()
+	pool.Borrow(5, false)
+
+	// print
+	fmt.Println(counters.String())
+
+	// Output:
+	// {"allocate": 1, "allocate_bytes": 5, "borrow": 1, "borrow_bytes": 123, "release": 1, "release_bytes": 123}
+}
+
+func TestRetain(t *testing.T) {
+	assert.Equal(t, Ref{}, Ref{}.Retain())
+
+	pool := NewPool()
+	pool.EnableStats()
+
+	_, ref1 := pool.Borrow(123, false)
+	ref2 := ref1.Retain()
+	assert.Equal(t, ref1, ref2)
+
+	ref1.Release()
+	assert.Equal(t, int64(1), pool.Stats().Classes[0].Outstanding)
+
+	ref2.Release()
+	assert.Equal(t, int64(0), pool.Stats().Classes[0].Outstanding)
+
+	assert.PanicsWithValue(t, "fpack: generation mismatch", func() {
+		ref2.Release()
+	})
+	assert.PanicsWithValue(t, "fpack: generation mismatch", func() {
+		ref2.Retain()
+	})
+
+	_, ref := pool.Borrow(123, false)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(ref Ref) {
+			defer wg.Done()
+			ref.Release()
+		}(ref.Retain())
+	}
+	ref.Release()
+	wg.Wait()
+
+	stats := pool.Stats().Classes[0]
+	assert.Equal(t, uint64(2), stats.Borrows)
+	assert.Equal(t, uint64(2), stats.Returns)
+}
+
+func TestSetWipe(t *testing.T) {
+	pool := NewPool()
+	pool.SetWipe(true)
+
+	buf, ref := pool.Borrow(123, false)
+	buf = buf[:cap(buf)]
+	for i := range buf {
+		buf[i] = 0xFF
+	}
+	ref.Release()
+
+	for _, b := range buf {
+		if b != 0 {
+			assert.Fail(t, "buffer not wiped")
+			break
+		}
+	}
+}
+
+func TestSetWipeReuse(t *testing.T) {
+	pool := NewPool()
+	pool.EnableStats()
+	pool.SetWipe(true)
+
+	buf, ref := pool.Borrow(100, false)
+	assert.Equal(t, 1024, cap(buf))
+	buf = buf[:cap(buf)]
+	for i := range buf {
+		buf[i] = 0xFF
+	}
+	ref.Release()
+
+	buf, ref = pool.Borrow(100, false)
+	defer ref.Release()
+	assert.Equal(t, make([]byte, cap(buf)), buf[:cap(buf)])
+
+	// the race detector randomly drops pooled values
+	if !raceEnabled {
+		assert.Equal(t, uint64(1), pool.Stats().Classes[0].News)
+	}
+}
+
+func TestPoison(t *testing.T) {
+	pool := NewPool()
+	pool.SetPoison(true)
+
+	buf, ref := pool.Borrow(1500, false)
+	copy(buf, "secret")
+	full := buf[:cap(buf)]
+	full[1600] = 42
+
+	ref.Release()
+	assert.Equal(t, bytes.Repeat([]byte{Poison}, 1500), buf)
+	assert.Equal(t, byte(42), full[1600])
+}
+
+func TestMaxIdleBytes(t *testing.T) {
+	pool := NewPool()
+	pool.EnableStats()
+	pool.SetMaxIdleBytes(16 << 20)
+
+	var refs []Ref
+	for i := 0; i < 4; i++ {
+		_, ref := pool.Borrow(5<<20, false)
+		refs = append(refs, ref)
+	}
+	for _, ref := range refs {
+		ref.Release()
+	}
+
+	stats := pool.Stats().Classes[13]
+	assert.Equal(t, 8<<20, stats.Size)
+	assert.Equal(t, uint64(4), stats.News)
+	assert.Equal(t, uint64(4), stats.Returns)
+	assert.Equal(t, uint64(2), stats.Drops)
+
+	for i := 0; i < 3; i++ {
+		_, ref := pool.Borrow(5<<20, false)
+		defer ref.Release()
+	}
+
+	stats = pool.Stats().Classes[13]
+	assert.Equal(t, uint64(5), stats.News)
+
+	_, ref := pool.Borrow(123, false)
+	ref.Release()
+	assert.Equal(t, uint64(0), pool.Stats().Classes[0].Drops)
+
+	pool.SetMaxIdleBytes(0)
+	assert.Nil(t, pool.idle)
+}
+
+func TestWarm(t *testing.T) {
+	pool := NewPool()
+	pool.EnableStats()
+	pool.SetMaxIdleBytes(8 << 20)
+
+	pool.Warm(3<<20, 3)
+	pool.Warm(5, 3)
+	pool.Warm(777<<17, 3)
+	assert.Equal(t, uint64(3), pool.Stats().Classes[12].News)
+	assert.Equal(t, uint64(1), pool.Stats().Classes[12].Drops)
+
+	_, ref1 := pool.Borrow(3<<20, false)
+	_, ref2 := pool.Borrow(3<<20, false)
+	assert.Equal(t, uint64(3), pool.Stats().Classes[12].News)
+
+	_, ref3 := pool.Borrow(3<<20, false)
+	assert.Equal(t, uint64(4), pool.Stats().Classes[12].News)
+
+	ref1.Release()
+	ref2.Release()
+	ref3.Release()
+}
+
 func TestGenerationOverflow(t *testing.T) {
-	global.gen = math.MaxUint64
+	Global().gen = math.MaxUint64
 	_, ref := Global().Borrow(123, false)
 	ref.Release()
 }
@@ -108,12 +504,146 @@ func TestRefInterface(t *testing.T) {
 	var _ interface {
 		Release()
 	} = Ref{}
+
+	var _ io.Closer = Ref{}
+}
+
+func TestRefClose(t *testing.T) {
+	assert.NoError(t, Ref{}.Close())
+
+	pool := NewPool()
+
+	_, ref := pool.Borrow(123, false)
+	assert.NoError(t, ref.Close())
+
+	assert.PanicsWithValue(t, "fpack: generation mismatch", func() {
+		_ = ref.Close()
+	})
+
+	pool.OnMisuse(func() {})
+
+	_, ref = pool.Borrow(123, false)
+	closers := []io.Closer{ref}
+	for _, closer := range closers {
+		assert.NoError(t, closer.Close())
+	}
+	assert.Equal(t, ErrGenerationMismatch, ref.Close())
+}
+
+func TestPoolStats(t *testing.T) {
+	pool := NewPool()
+	assert.Equal(t, PoolStats{}, pool.Stats())
+
+	pool.EnableStats()
+	stats := pool.Stats()
+	assert.Len(t, stats.Classes, 16)
+	assert.Equal(t, 1<<10, stats.Classes[0].Size)
+	assert.Equal(t, 32<<20, stats.Classes[15].Size)
+
+	_, ref1 := pool.Borrow(123, false)
+	_, ref2 := pool.Borrow(123, false)
+	_, ref3 := pool.Borrow(4000, false)
+	pool.Borrow(4, false)
+	pool.Borrow(64<<20, false)
+
+	stats = pool.Stats()
+	assert.Equal(t, ClassStats{Size: 1 << 10, Borrows: 2, News: 2, Outstanding: 2}, stats.Classes[0])
+	assert.Equal(t, ClassStats{Size: 4 << 10, Borrows: 1, News: 1, Outstanding: 1}, stats.Classes[2])
+	assert.Equal(t, uint64(1), stats.Small)
+	assert.Equal(t, uint64(1), stats.Large)
+
+	ref1.Release()
+	ref2.Release()
+	ref3.Release()
+
+	stats = pool.Stats()
+	assert.Equal(t, uint64(2), stats.Classes[0].Returns)
+	assert.Equal(t, int64(0), stats.Classes[0].Outstanding)
+	assert.Equal(t, int64(0), stats.Classes[2].Outstanding)
+
+	assert.Equal(t, 0.0, testing.AllocsPerRun(100, func() {
+		_, ref := pool.Borrow(123, false)
+		ref.Release()
+	}))
 }
 
 func TestClone(t *testing.T) {
 	buf, ref := Global().Clone([]byte("foo"))
 	assert.Equal(t, []byte("foo"), buf)
 	ref.Release()
+
+	msg, msgRef := Global().Borrow(1<<20, false)
+	copy(msg, "0123456789abcdef")
+	key, keyRef := Global().Clone(msg[:16])
+	msgRef.Release()
+	assert.Equal(t, []byte("0123456789abcdef"), key)
+	assert.Equal(t, 1<<10, cap(key))
+	keyRef.Release()
+}
+
+func TestResize(t *testing.T) {
+	pool := NewPool()
+
+	buf, ref1 := pool.Borrow(100, false)
+	copy(buf, "foo")
+
+	buf, ref2 := pool.Resize(buf, ref1, 1000)
+	assert.Equal(t, 1000, len(buf))
+	assert.Equal(t, "foo", string(buf[:3]))
+	assert.Equal(t, ref1, ref2)
+
+	buf, ref3 := pool.Resize(buf, ref2, 5000)
+	assert.Equal(t, 5000, len(buf))
+	assert.Equal(t, 8192, cap(buf))
+	assert.Equal(t, "foo", string(buf[:3]))
+	assert.NotEqual(t, ref2, ref3)
+
+	assert.PanicsWithValue(t, "fpack: generation mismatch", func() {
+		ref2.Release()
+	})
+
+	buf, ref4 := pool.Resize(buf[:3], ref3, 3)
+	assert.Equal(t, "foo", string(buf))
+	assert.Equal(t, ref3, ref4)
+	ref4.Release()
+
+	buf, ref := pool.Resize([]byte("bar"), Ref{}, 50)
+	assert.Equal(t, 50, len(buf))
+	assert.Equal(t, "bar", string(buf[:3]))
+	ref.Release()
+}
+
+func TestRefClone(t *testing.T) {
+	pool := NewPool()
+	pool.EnableStats()
+
+	msg, msgRef := pool.Borrow(1<<20, false)
+	copy(msg, "0123456789abcdef")
+	key, keyRef := msgRef.Clone(nil, msg[:16])
+	msgRef.Release()
+	assert.Equal(t, []byte("0123456789abcdef"), key)
+	assert.Equal(t, 1<<10, cap(key))
+	assert.Equal(t, uint64(1), pool.Stats().Classes[0].Borrows)
+	keyRef.Release()
+
+	key, keyRef = Ref{}.Clone(pool, []byte("foo"))
+	assert.Equal(t, []byte("foo"), key)
+	assert.Equal(t, uint64(1), pool.Stats().Small)
+	keyRef.Release()
+
+	assert.PanicsWithValue(t, "fpack: generation mismatch", func() {
+		msgRef.Clone(nil, msg[:16])
+	})
+
+	var misuses int
+	pool.OnMisuse(func() {
+		misuses++
+	})
+
+	key, keyRef = msgRef.Clone(nil, msg[:16])
+	assert.Equal(t, 1, misuses)
+	assert.Nil(t, key)
+	assert.Zero(t, keyRef)
 }
 
 func TestConcat(t *testing.T) {
@@ -142,6 +672,42 @@ func BenchmarkBorrowZero(b *testing.B) {
 	}
 }
 
+func BenchmarkBorrowWipe(b *testing.B) {
+	pool := NewPool()
+	pool.SetWipe(true)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_, ref := pool.Borrow(1<<16, false)
+		ref.Release()
+	}
+}
+
+func BenchmarkBorrowFirst(b *testing.B) {
+	for _, warm := range []bool{false, true} {
+		b.Run(strconv.FormatBool(warm), func(b *testing.B) {
+			b.ReportAllocs()
+
+			var total time.Duration
+			for i := 0; i < b.N; i++ {
+				pool := NewPool()
+				if warm {
+					pool.Warm(1<<20, 1)
+				}
+
+				start := time.Now()
+				_, ref := pool.Borrow(1<<20, false)
+				total += time.Since(start)
+				ref.Release()
+			}
+
+			b.ReportMetric(float64(total.Nanoseconds())/float64(b.N), "borrow-ns/op")
+		})
+	}
+}
+
 func BenchmarkPoolClasses(b *testing.B) {
 	if testing.Short() {
 		b.Skip()
@@ -0,0 +1,111 @@
+package fpack
+
+import "errors"
+
+// ErrInvalidWireType is returned if a protobuf wire type is invalid.
+var ErrInvalidWireType = errors.New("invalid wire type")
+
+// ErrInvalidFieldNumber is returned if a protobuf field number is invalid.
+var ErrInvalidFieldNumber = errors.New("invalid field number")
+
+// The supported protobuf wire types. Fixed size values are encoded in little
+// endian byte order by protobuf.
+const (
+	ProtoVarint  = 0
+	ProtoFixed64 = 1
+	ProtoBytes   = 2
+	ProtoFixed32 = 5
+)
+
+const protoMaxField = 1<<29 - 1
+
+func validProtoWireType(wireType int) bool {
+	switch wireType {
+	case ProtoVarint, ProtoFixed64, ProtoBytes, ProtoFixed32:
+		return true
+	default:
+		return false
+	}
+}
+
+// ProtoKey writes a protobuf field key.
+func (e *Encoder) ProtoKey(fieldNum int, wireType int) {
+	// skip if errored
+	if e.err != nil {
+		return
+	}
+
+	// check field number
+	if fieldNum < 1 || fieldNum > protoMaxField {
+		e.err = ErrInvalidFieldNumber
+		return
+	}
+
+	// check wire type
+	if !validProtoWireType(wireType) {
+		e.err = ErrInvalidWireType
+		return
+	}
+
+	// write key
+	e.VarUint(uint64(fieldNum)<<3 | uint64(wireType))
+}
+
+// ProtoKey reads a protobuf field key and returns the field number and wire
+// type.
+func (d *Decoder) ProtoKey() (int, int) {
+	// read key
+	start := d.Offset()
+	key := d.varuint("protokey")
+	if d.err != nil {
+		return 0, 0
+	}
+
+	// split key
+	fieldNum := key >> 3
+	wireType := int(key & 7)
+
+	// check field number
+	if fieldNum < 1 || fieldNum > protoMaxField {
+		d.failAt(ErrInvalidFieldNumber, "protokey", start, d.Offset()-start)
+		return 0, 0
+	}
+
+	// check wire type
+	if !validProtoWireType(wireType) {
+		d.failAt(ErrInvalidWireType, "protokey", start, d.Offset()-start)
+		return 0, 0
+	}
+
+	return int(fieldNum), wireType
+}
+
+// ProtoSkip skips the payload of a protobuf field with the specified wire type.
+func (d *Decoder) ProtoSkip(wireType int) {
+	// skip if errored
+	if d.err != nil {
+		return
+	}
+
+	// skip payload
+	switch wireType {
+	case ProtoVarint:
+		d.varuint("protoskip")
+	case ProtoFixed64:
+		d.skip("protoskip", 8)
+	case ProtoBytes:
+		length := d.varuint("protoskip")
+		if d.err != nil {
+			return
+		}
+		if length > uint64(d.Length()) {
+			d.fail(ErrBufferTooShort, "protoskip", 0)
+			return
+		}
+		d.skip("protoskip", int(length))
+	case ProtoFixed32:
+		d.skip("protoskip", 4)
+	default:
+		d.fail(ErrInvalidWireType, "protoskip", 0)
+	}
+}
@@ -0,0 +1,86 @@
+package fpack
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProto(t *testing.T) {
+	buf, _, err := Encode(nil, func(enc *Encoder) error {
+		enc.UseLittleEndian()
+		enc.ProtoKey(1, ProtoVarint)
+		enc.VarUint(150)
+		enc.ProtoKey(2, ProtoBytes)
+		enc.VarString("foo")
+		enc.ProtoKey(3, ProtoFixed32)
+		enc.Uint32(42)
+		enc.ProtoKey(4, ProtoFixed64)
+		enc.Uint64(42)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{
+		0x08, 0x96, 0x01,
+		0x12, 0x03, 'f', 'o', 'o',
+		0x1d, 42, 0, 0, 0,
+		0x21, 42, 0, 0, 0, 0, 0, 0, 0,
+	}, buf)
+
+	var num uint64
+	var fields []int
+	err = Decode(buf, func(dec *Decoder) error {
+		dec.UseLittleEndian()
+		for dec.Remaining() {
+			field, wireType := dec.ProtoKey()
+			fields = append(fields, field)
+			if field == 1 {
+				num = dec.VarUint()
+			} else {
+				dec.ProtoSkip(wireType)
+			}
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(150), num)
+	assert.Equal(t, []int{1, 2, 3, 4}, fields)
+}
+
+func TestProtoErrors(t *testing.T) {
+	_, _, err := Encode(nil, func(enc *Encoder) error {
+		enc.ProtoKey(1, 3)
+		return nil
+	})
+	assert.Equal(t, ErrInvalidWireType, err)
+
+	_, _, err = Encode(nil, func(enc *Encoder) error {
+		enc.ProtoKey(0, ProtoVarint)
+		return nil
+	})
+	assert.Equal(t, ErrInvalidFieldNumber, err)
+
+	err = Decode([]byte{0x0b}, func(dec *Decoder) error {
+		dec.ProtoKey()
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrInvalidWireType)
+
+	err = Decode([]byte{0x02}, func(dec *Decoder) error {
+		dec.ProtoKey()
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrInvalidFieldNumber)
+
+	err = Decode(nil, func(dec *Decoder) error {
+		dec.ProtoSkip(4)
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrInvalidWireType)
+
+	err = Decode([]byte{0x05, 1, 2}, func(dec *Decoder) error {
+		dec.ProtoSkip(ProtoBytes)
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrBufferTooShort)
+}
@@ -0,0 +1,45 @@
+//go:build go1.19
+
+package fpack
+
+import "encoding/binary"
+
+// putUint writes the lower size bytes of the number to the start of the buffer
+// using the provided byte order. The standard byte orders are written in place
+// using their inlined append helpers instead of the dynamically dispatched put
+// methods.
+func putUint(bo binary.ByteOrder, buf []byte, num uint64, size int) {
+	switch {
+	case isOrder(bo, binary.BigEndian):
+		switch size {
+		case 1:
+			buf[0] = uint8(num)
+		case 2:
+			binary.BigEndian.AppendUint16(buf[:0], uint16(num))
+		case 4:
+			binary.BigEndian.AppendUint32(buf[:0], uint32(num))
+		case 8:
+			binary.BigEndian.AppendUint64(buf[:0], num)
+		}
+	case isOrder(bo, binary.LittleEndian):
+		switch size {
+		case 1:
+			buf[0] = uint8(num)
+		case 2:
+			binary.LittleEndian.AppendUint16(buf[:0], uint16(num))
+		case 4:
+			binary.LittleEndian.AppendUint32(buf[:0], uint32(num))
+		case 8:
+			binary.LittleEndian.AppendUint64(buf[:0], num)
+		}
+	default:
+		putUintAny(bo, buf, num, size)
+	}
+}
+
+// isOrder returns whether the byte order has the same type as the provided
+// order. Unlike an interface comparison, it does not call into the runtime.
+func isOrder[T binary.ByteOrder](bo binary.ByteOrder, _ T) bool {
+	_, ok := bo.(T)
+	return ok
+}
@@ -0,0 +1,11 @@
+//go:build !go1.19
+
+package fpack
+
+import "encoding/binary"
+
+// putUint writes the lower size bytes of the number to the start of the buffer
+// using the provided byte order.
+func putUint(bo binary.ByteOrder, buf []byte, num uint64, size int) {
+	putUintAny(bo, buf, num, size)
+}
@@ -0,0 +1,29 @@
+package fpack
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type customOrder struct {
+	binary.ByteOrder
+}
+
+func TestPutUint(t *testing.T) {
+	for _, bo := range []binary.ByteOrder{binary.BigEndian, binary.LittleEndian, customOrder{binary.LittleEndian}} {
+		for _, size := range []int{1, 2, 4, 8} {
+			num := uint64(0x0102030405060708) >> (64 - size*8)
+
+			buf1 := make([]byte, 9)
+			putUint(bo, buf1, num, size)
+
+			buf2 := make([]byte, 9)
+			putUintAny(bo, buf2, num, size)
+
+			assert.Equal(t, buf2, buf1, size)
+			assert.Equal(t, byte(0), buf1[size])
+		}
+	}
+}
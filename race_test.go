@@ -0,0 +1,7 @@
+//go:build race
+
+package fpack
+
+// raceEnabled is true if the race detector is enabled, which randomly drops
+// pooled values and therefore breaks allocation assertions.
+const raceEnabled = true
@@ -0,0 +1,147 @@
+package fpack
+
+import "encoding/binary"
+
+type opCode uint8
+
+const (
+	opSkip opCode = iota
+	opInt
+	opUint
+	opVarInt
+	opVarUint
+	opData
+	opBER
+	opFunc
+	opFill
+)
+
+type op struct {
+	code opCode
+	size int
+	num  uint64
+	bo   binary.ByteOrder
+	fn   func(enc *Encoder)
+}
+
+// EncodeRecorded will encode data using the provided encoding function like
+// Encode. However, the function is only run once while the performed operations
+// are recorded and then replayed to encode the data. Written strings and byte
+// slices are copied into the recording. A tracer set during recording is called
+// during the replay, with recorded data reported as "bytes".
+func EncodeRecorded(pool *Pool, fn func(enc *Encoder) error) ([]byte, Ref, error) {
+	// borrow
+	enc := encoderPool.Get().(*Encoder)
+
+	// recycle
+	defer func() {
+		enc.Reset(nil)
+		encoderPool.Put(enc)
+	}()
+
+	// record
+	enc.rcd = true
+	err := fn(enc)
+	if err != nil {
+		return nil, Ref{}, err
+	}
+
+	// check error
+	err = enc.Error()
+	if err != nil {
+		return nil, Ref{}, err
+	}
+
+	// get length
+	length := enc.Length()
+
+	// get buffer
+	var buf []byte
+	var ref Ref
+	if pool != nil {
+		buf, ref = pool.Borrow(length, false)
+	} else {
+		buf = make([]byte, length)
+	}
+
+	// reset encoder but keep tracer
+	trc := enc.trc
+	enc.Reset(buf)
+	enc.trc = trc
+
+	// replay
+	enc.replay()
+
+	// check error
+	err = enc.Error()
+	if err != nil {
+		ref.Release()
+		return nil, Ref{}, err
+	}
+
+	return buf, ref, nil
+}
+
+func (e *Encoder) record(o op) {
+	e.rec = append(e.rec, o)
+}
+
+func (e *Encoder) recordString(str string) {
+	e.record(op{code: opData, num: uint64(len(e.dat)), size: len(str)})
+	e.dat = append(e.dat, str...)
+}
+
+func (e *Encoder) recordBytes(buf []byte) {
+	e.record(op{code: opData, num: uint64(len(e.dat)), size: len(buf)})
+	e.dat = append(e.dat, buf...)
+}
+
+func (e *Encoder) render(num int, fn func(enc *Encoder)) {
+	// prepare encoder
+	off := len(e.dat)
+	e.dat = append(e.dat, make([]byte, num)...)
+	enc := Encoder{
+		bo:  e.bo,
+		org: e.dat[off:],
+		buf: e.dat[off:],
+	}
+
+	// render
+	fn(&enc)
+	if enc.err != nil {
+		e.err = enc.err
+		return
+	}
+
+	// record
+	e.record(op{code: opData, num: uint64(off), size: num})
+}
+
+func (e *Encoder) replay() {
+	// replay operations
+	for i := 0; i < len(e.rec) && e.err == nil; i++ {
+		o := e.rec[i]
+		switch o.code {
+		case opSkip:
+			e.Skip(o.size)
+		case opInt:
+			e.bo = o.bo
+			e.Int(int64(o.num), o.size)
+		case opUint:
+			e.bo = o.bo
+			e.Uint(o.num, o.size)
+		case opVarInt:
+			e.VarInt(int64(o.num))
+		case opVarUint:
+			e.VarUint(o.num)
+		case opData:
+			e.Bytes(e.dat[o.num : o.num+uint64(o.size)])
+		case opBER:
+			e.BERLength(o.size)
+		case opFunc:
+			o.fn(e)
+		case opFill:
+			e.Fill(o.size, byte(o.num))
+		}
+	}
+}
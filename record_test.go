@@ -0,0 +1,156 @@
+package fpack
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeRecorded(t *testing.T) {
+	withAndWithoutPool(func(pool *Pool) {
+		var calls int
+		buf, ref, err := EncodeRecorded(pool, func(enc *Encoder) error {
+			calls++
+			encodeDummy(enc)
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, calls)
+		assert.Equal(t, dummy, buf)
+		ref.Release()
+
+		fn := func(enc *Encoder) error {
+			res := enc.Reserve(4)
+			enc.UseLittleEndian()
+			enc.Uint16(42)
+			enc.SetByteOrder(nil)
+			enc.UseLittleEndian()
+			enc.HexString([]byte("foo"), 1)
+			enc.GroupUint32(1, 1<<8, 1<<16, 1<<24)
+			enc.PackedASCII("hello")
+			enc.UTF16String("h€", 1)
+			enc.BERBlock(func(enc *Encoder) {
+				enc.String(strings.Repeat("x", 200))
+				enc.BERLength(300)
+			})
+			enc.CopyN(strings.NewReader("bar"), 3)
+			enc.Fill(5, ' ')
+			enc.PatchUint32(res, uint32(enc.Offset()))
+			enc.HMAC(sha256.New, []byte("secret"))
+			return nil
+		}
+
+		buf1, ref1, err := Encode(pool, fn)
+		assert.NoError(t, err)
+
+		buf2, ref2, err := EncodeRecorded(pool, fn)
+		assert.NoError(t, err)
+		assert.Equal(t, buf1, buf2)
+
+		ref1.Release()
+		ref2.Release()
+
+		buf, ref, err = EncodeRecorded(pool, func(enc *Encoder) error {
+			enc.CopyN(bytes.NewReader([]byte{1}), 2)
+			return nil
+		})
+		assert.Equal(t, io.ErrUnexpectedEOF, err)
+		assert.Nil(t, buf)
+		assert.Zero(t, ref)
+
+		buf, ref, err = EncodeRecorded(pool, func(enc *Encoder) error {
+			return io.EOF
+		})
+		assert.Equal(t, io.EOF, err)
+		assert.Nil(t, buf)
+		assert.Zero(t, ref)
+	})
+}
+
+func TestEncodeRecordedPatch(t *testing.T) {
+	fn := func(enc *Encoder) error {
+		res := enc.Reserve(4)
+		enc.UseLittleEndian()
+		enc.PatchUint32(res, 1)
+		enc.SetByteOrder(binary.BigEndian)
+		enc.Uint16(2)
+		return nil
+	}
+
+	buf1, _, err := Encode(nil, fn)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{1, 0, 0, 0, 0, 2}, buf1)
+
+	buf2, _, err := EncodeRecorded(nil, fn)
+	assert.NoError(t, err)
+	assert.Equal(t, buf1, buf2)
+}
+
+func TestEncodeRecordedTrace(t *testing.T) {
+	var ops []string
+	buf, _, err := EncodeRecorded(nil, func(enc *Encoder) error {
+		enc.Trace(func(op string, offset, length int) {
+			ops = append(ops, fmt.Sprintf("%s@%d:%d", op, offset, length))
+		})
+		enc.Uint16(1)
+		enc.VarString("foo")
+		enc.Bool(true)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Len(t, buf, 7)
+	assert.Equal(t, []string{
+		"uint@0:2",
+		"varuint@2:1",
+		"bytes@3:3",
+		"uint@6:1",
+	}, ops)
+}
+
+func TestEncodeRecordedOrdered(t *testing.T) {
+	for name, fn := range map[string]func(enc *Encoder){
+		"OrderedString": func(enc *Encoder) {
+			enc.OrderedString("a\x00bc")
+		},
+		"OrderedInt16": func(enc *Encoder) {
+			enc.OrderedInt16(-42)
+		},
+		"OrderedInt32": func(enc *Encoder) {
+			enc.OrderedInt32(-42)
+		},
+		"OrderedInt64": func(enc *Encoder) {
+			enc.OrderedInt64(-42)
+		},
+		"OrderedFloat64": func(enc *Encoder) {
+			enc.OrderedFloat64(-4.2)
+		},
+		"DescUint64": func(enc *Encoder) {
+			enc.DescUint64(42)
+		},
+		"DescOrderedString": func(enc *Encoder) {
+			enc.DescOrderedString("abc")
+			enc.DescOrderedString("a\x00bc")
+			enc.DescOrderedString("")
+		},
+	} {
+		fn := func(enc *Encoder) error {
+			enc.Uint8(1)
+			fn(enc)
+			enc.Uint8(2)
+			return nil
+		}
+
+		buf1, _, err := Encode(nil, fn)
+		assert.NoError(t, err, name)
+
+		buf2, _, err := EncodeRecorded(nil, fn)
+		assert.NoError(t, err, name)
+		assert.Equal(t, buf1, buf2, name)
+	}
+}
@@ -0,0 +1,431 @@
+package fpack
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrInvalidTag is returned if a struct field has an invalid fpack tag.
+var ErrInvalidTag = errors.New("invalid tag")
+
+type coder struct {
+	enc func(enc *Encoder, v reflect.Value)
+	dec func(dec *Decoder, v reflect.Value)
+}
+
+type coderEntry struct {
+	coder *coder
+	err   error
+}
+
+var coders sync.Map
+
+type structField struct {
+	index int
+	order int
+	coder *coder
+}
+
+// Marshal will encode the provided value using reflection. Struct fields are
+// encoded in declaration order and unexported fields or fields tagged with
+// `fpack:"-"` are skipped. Booleans, integers and floats use their fixed size
+// representation while int and uint use eight bytes. Strings and byte slices
+// are variable length prefixed, other slices are encoded as lists and arrays
+// as a sequence of items. Pointers are encoded as optional values. Integer
+// fields tagged with `fpack:"varint"` are encoded as variable integers and
+// string or byte slice fields tagged with `fpack:"fixstring:N"` use a fixed
+// length prefix of N bytes. Fields with an `fpack:"order:N"` option, which
+// may be combined with other options using a comma, are encoded first sorted
+// by their order to keep the format stable when fields are reordered. Other
+// kinds result in ErrUnsupportedType. The encoding plan of each type is cached.
+func Marshal(v any) ([]byte, error) {
+	// get coder
+	c, err := typeCoder(reflect.TypeOf(v))
+	if err != nil {
+		return nil, err
+	}
+
+	// encode
+	buf, _, err := Encode(nil, func(enc *Encoder) error {
+		c.enc(enc, reflect.ValueOf(v))
+		return nil
+	})
+
+	return buf, err
+}
+
+// Unmarshal will decode the provided buffer into the value pointed to by v
+// using reflection. See Marshal for details on the encoding. Strings and byte
+// slices are cloned.
+func Unmarshal(buf []byte, v any) error {
+	// check value
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("%w: %T", ErrUnsupportedType, v)
+	}
+
+	// get coder
+	c, err := typeCoder(rv.Elem().Type())
+	if err != nil {
+		return err
+	}
+
+	return Decode(buf, func(dec *Decoder) error {
+		c.dec(dec, rv.Elem())
+		return nil
+	})
+}
+
+func typeCoder(typ reflect.Type) (*coder, error) {
+	// check type
+	if typ == nil {
+		return nil, ErrUnsupportedType
+	}
+
+	// check cache
+	if entry, ok := coders.Load(typ); ok {
+		return entry.(*coderEntry).coder, entry.(*coderEntry).err
+	}
+
+	// build and cache coder
+	c, err := buildCoder(typ, "", map[reflect.Type]*coder{})
+	if err != nil {
+		c = nil
+	}
+	coders.Store(typ, &coderEntry{coder: c, err: err})
+
+	return c, err
+}
+
+func buildCoder(typ reflect.Type, tag string, seen map[reflect.Type]*coder) (*coder, error) {
+	// handle pointers
+	if typ.Kind() == reflect.Pointer {
+		// get value coder
+		value, err := buildCoder(typ.Elem(), tag, seen)
+		if err != nil {
+			return nil, err
+		}
+
+		return &coder{
+			enc: func(enc *Encoder, v reflect.Value) {
+				enc.Option(!v.IsNil(), func(enc *Encoder) {
+					value.enc(enc, v.Elem())
+				})
+			},
+			dec: func(dec *Decoder, v reflect.Value) {
+				v.Set(reflect.Zero(typ))
+				dec.Option(func(dec *Decoder) {
+					ptr := reflect.New(typ.Elem())
+					value.dec(dec, ptr.Elem())
+					v.Set(ptr)
+				})
+			},
+		}, nil
+	}
+
+	// parse tag
+	name, arg, _ := strings.Cut(tag, ":")
+	var lenSize int
+	switch name {
+	case "":
+	case "varint":
+		switch typ.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		default:
+			return nil, fmt.Errorf("%w: %q on %s", ErrInvalidTag, tag, typ)
+		}
+	case "fixstring":
+		var err error
+		lenSize, err = strconv.Atoi(arg)
+		if err != nil || (lenSize != 1 && lenSize != 2 && lenSize != 4 && lenSize != 8) {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidTag, tag)
+		} else if typ.Kind() != reflect.String && (typ.Kind() != reflect.Slice || typ.Elem().Kind() != reflect.Uint8) {
+			return nil, fmt.Errorf("%w: %q on %s", ErrInvalidTag, tag, typ)
+		}
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrInvalidTag, tag)
+	}
+
+	// handle kind
+	switch typ.Kind() {
+	case reflect.Bool:
+		return &coder{
+			enc: func(enc *Encoder, v reflect.Value) {
+				enc.Bool(v.Bool())
+			},
+			dec: func(dec *Decoder, v reflect.Value) {
+				v.SetBool(dec.Bool())
+			},
+		}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		size := int(typ.Size())
+		if typ.Kind() == reflect.Int {
+			size = 8
+		}
+		return &coder{
+			enc: func(enc *Encoder, v reflect.Value) {
+				if name == "varint" {
+					enc.VarInt(v.Int())
+				} else {
+					enc.Int(v.Int(), size)
+				}
+			},
+			dec: func(dec *Decoder, v reflect.Value) {
+				start := dec.Offset()
+				var num int64
+				if name == "varint" {
+					num = dec.VarInt()
+				} else {
+					num = dec.Int(size)
+				}
+				if v.OverflowInt(num) {
+					dec.failAt(ErrNumberOverflow, "unmarshal", start, dec.Offset()-start)
+					return
+				}
+				v.SetInt(num)
+			},
+		}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		size := int(typ.Size())
+		if typ.Kind() == reflect.Uint {
+			size = 8
+		}
+		return &coder{
+			enc: func(enc *Encoder, v reflect.Value) {
+				if name == "varint" {
+					enc.VarUint(v.Uint())
+				} else {
+					enc.Uint(v.Uint(), size)
+				}
+			},
+			dec: func(dec *Decoder, v reflect.Value) {
+				start := dec.Offset()
+				var num uint64
+				if name == "varint" {
+					num = dec.VarUint()
+				} else {
+					num = dec.Uint(size)
+				}
+				if v.OverflowUint(num) {
+					dec.failAt(ErrNumberOverflow, "unmarshal", start, dec.Offset()-start)
+					return
+				}
+				v.SetUint(num)
+			},
+		}, nil
+	case reflect.Float32:
+		return &coder{
+			enc: func(enc *Encoder, v reflect.Value) {
+				enc.Float32(float32(v.Float()))
+			},
+			dec: func(dec *Decoder, v reflect.Value) {
+				v.SetFloat(float64(dec.Float32()))
+			},
+		}, nil
+	case reflect.Float64:
+		return &coder{
+			enc: func(enc *Encoder, v reflect.Value) {
+				enc.Float64(v.Float())
+			},
+			dec: func(dec *Decoder, v reflect.Value) {
+				v.SetFloat(dec.Float64())
+			},
+		}, nil
+	case reflect.String:
+		return &coder{
+			enc: func(enc *Encoder, v reflect.Value) {
+				if lenSize > 0 {
+					enc.FixString(v.String(), lenSize)
+				} else {
+					enc.VarString(v.String())
+				}
+			},
+			dec: func(dec *Decoder, v reflect.Value) {
+				if lenSize > 0 {
+					v.SetString(dec.FixString(lenSize, true))
+				} else {
+					v.SetString(dec.VarString(true))
+				}
+			},
+		}, nil
+	case reflect.Slice:
+		// handle byte slices
+		if typ.Elem().Kind() == reflect.Uint8 {
+			return &coder{
+				enc: func(enc *Encoder, v reflect.Value) {
+					if lenSize > 0 {
+						enc.FixBytes(v.Bytes(), lenSize)
+					} else {
+						enc.VarBytes(v.Bytes())
+					}
+				},
+				dec: func(dec *Decoder, v reflect.Value) {
+					if lenSize > 0 {
+						v.SetBytes(dec.FixBytes(lenSize, true))
+					} else {
+						v.SetBytes(dec.VarBytes(true))
+					}
+				},
+			}, nil
+		}
+
+		// get item coder
+		item, err := buildCoder(typ.Elem(), "", seen)
+		if err != nil {
+			return nil, err
+		}
+
+		return &coder{
+			enc: func(enc *Encoder, v reflect.Value) {
+				enc.List(v.Len(), func(enc *Encoder, i int) {
+					item.enc(enc, v.Index(i))
+				})
+			},
+			dec: func(dec *Decoder, v reflect.Value) {
+				// read count
+				num := dec.varuint("unmarshal")
+				if dec.err != nil {
+					return
+				}
+
+				// check count
+				if !dec.count("unmarshal", num, dec.Length()) {
+					return
+				}
+
+				// decode items
+				list := reflect.MakeSlice(typ, int(num), int(num))
+				for i := 0; i < int(num) && dec.err == nil; i++ {
+					item.dec(dec, list.Index(i))
+				}
+				v.Set(list)
+			},
+		}, nil
+	case reflect.Array:
+		// get item coder
+		item, err := buildCoder(typ.Elem(), "", seen)
+		if err != nil {
+			return nil, err
+		}
+
+		return &coder{
+			enc: func(enc *Encoder, v reflect.Value) {
+				for i := 0; i < v.Len(); i++ {
+					item.enc(enc, v.Index(i))
+				}
+			},
+			dec: func(dec *Decoder, v reflect.Value) {
+				for i := 0; i < v.Len() && dec.err == nil; i++ {
+					item.dec(dec, v.Index(i))
+				}
+			},
+		}, nil
+	case reflect.Struct:
+		// handle recursive types
+		if c, ok := seen[typ]; ok {
+			return c, nil
+		}
+		c := &coder{}
+		seen[typ] = c
+
+		// collect fields
+		var fields []structField
+		for i := 0; i < typ.NumField(); i++ {
+			// get field
+			field := typ.Field(i)
+			tag := field.Tag.Get("fpack")
+			if !field.IsExported() || tag == "-" {
+				continue
+			}
+
+			// parse tag
+			rep, order, err := parseTag(tag)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", field.Name, err)
+			}
+
+			// get field coder
+			fc, err := buildCoder(field.Type, rep, seen)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", field.Name, err)
+			}
+
+			fields = append(fields, structField{
+				index: i,
+				order: order,
+				coder: fc,
+			})
+		}
+
+		// order fields
+		err := orderFields(fields)
+		if err != nil {
+			return nil, err
+		}
+
+		// set functions
+		c.enc = func(enc *Encoder, v reflect.Value) {
+			for _, field := range fields {
+				field.coder.enc(enc, v.Field(field.index))
+			}
+		}
+		c.dec = func(dec *Decoder, v reflect.Value) {
+			for _, field := range fields {
+				if dec.err != nil {
+					return
+				}
+				field.coder.dec(dec, v.Field(field.index))
+			}
+		}
+
+		return c, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedType, typ)
+	}
+}
+
+func parseTag(tag string) (string, int, error) {
+	// parse options
+	rep, order := "", -1
+	for _, opt := range strings.Split(tag, ",") {
+		if strings.HasPrefix(opt, "order:") {
+			n, err := strconv.Atoi(opt[6:])
+			if err != nil || n < 0 || order >= 0 {
+				return "", 0, fmt.Errorf("%w: %q", ErrInvalidTag, tag)
+			}
+			order = n
+		} else if rep == "" {
+			rep = opt
+		} else {
+			return "", 0, fmt.Errorf("%w: %q", ErrInvalidTag, tag)
+		}
+	}
+
+	return rep, order, nil
+}
+
+func orderFields(fields []structField) error {
+	// sort fields
+	sort.SliceStable(fields, func(i, j int) bool {
+		oi, oj := fields[i].order, fields[j].order
+		if oi < 0 || oj < 0 {
+			return oi >= 0 && oj < 0
+		}
+		return oi < oj
+	})
+
+	// check duplicates
+	for i := 1; i < len(fields); i++ {
+		if fields[i].order >= 0 && fields[i].order == fields[i-1].order {
+			return fmt.Errorf("%w: duplicate order %d", ErrInvalidTag, fields[i].order)
+		}
+	}
+
+	return nil
+}
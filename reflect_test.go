@@ -0,0 +1,162 @@
+package fpack
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type reflectItem struct {
+	Name string `fpack:"fixstring:1"`
+	Next *reflectItem
+}
+
+type reflectValue struct {
+	Bool    bool
+	Int     int
+	Int8    int8
+	Int16   int16 `fpack:"varint"`
+	Uint32  uint32
+	Uint    uint `fpack:"varint"`
+	Float32 float32
+	Float64 float64
+	String  string
+	Bytes   []byte `fpack:"fixstring:2"`
+	Skip    string `fpack:"-"`
+	skip    string
+	Array   [2]uint8
+	List    []reflectItem
+	Ptr     *int32 `fpack:"varint"`
+	Nil     *int32
+}
+
+func TestMarshal(t *testing.T) {
+	num := int32(-7)
+	value := reflectValue{
+		Bool:    true,
+		Int:     -1,
+		Int8:    -2,
+		Int16:   -3,
+		Uint32:  4,
+		Uint:    300,
+		Float32: 1.5,
+		Float64: 2.5,
+		String:  "foo",
+		Bytes:   []byte("bar"),
+		Skip:    "skip",
+		skip:    "skip",
+		Array:   [2]uint8{5, 6},
+		List: []reflectItem{
+			{Name: "a", Next: &reflectItem{Name: "b"}},
+			{Name: "c"},
+		},
+		Ptr: &num,
+	}
+
+	buf, err := Marshal(value)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{
+		1,
+		0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF,
+		0xFE,
+		0x05,
+		0, 0, 0, 4,
+		0xAC, 0x02,
+		0x3F, 0xC0, 0, 0,
+		0x40, 0x04, 0, 0, 0, 0, 0, 0,
+		3, 'f', 'o', 'o',
+		0, 3, 'b', 'a', 'r',
+		5, 6,
+		2,
+		1, 'a', 1, 1, 'b', 0,
+		1, 'c', 0,
+		1, 0x0D,
+		0,
+	}, buf)
+
+	var out reflectValue
+	err = Unmarshal(buf, &out)
+	assert.NoError(t, err)
+	value.Skip = ""
+	value.skip = ""
+	assert.Equal(t, value, out)
+
+	buf, err = Marshal(&value)
+	assert.NoError(t, err)
+	assert.Equal(t, byte(1), buf[0])
+
+	err = Unmarshal(buf[:10], &out)
+	assert.ErrorIs(t, err, ErrBufferTooShort)
+}
+
+func TestMarshalOrder(t *testing.T) {
+	buf, err := Marshal(struct {
+		A uint8 `fpack:"order:2"`
+		B uint8
+		C uint16 `fpack:"varint,order:1"`
+		D uint8
+	}{A: 1, B: 2, C: 3, D: 4})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{3, 1, 2, 4}, buf)
+
+	_, err = Marshal(struct {
+		A uint8 `fpack:"order:1"`
+		B uint8 `fpack:"order:1"`
+	}{})
+	assert.True(t, errors.Is(err, ErrInvalidTag))
+
+	_, err = Marshal(struct {
+		A uint8 `fpack:"varint,order:1,order:2"`
+	}{})
+	assert.True(t, errors.Is(err, ErrInvalidTag))
+
+	_, err = Marshal(struct {
+		A string `fpack:"varint,fixstring:1"`
+	}{})
+	assert.True(t, errors.Is(err, ErrInvalidTag))
+}
+
+func TestMarshalErrors(t *testing.T) {
+	_, err := Marshal(nil)
+	assert.Equal(t, ErrUnsupportedType, err)
+
+	_, err = Marshal(map[string]int{})
+	assert.True(t, errors.Is(err, ErrUnsupportedType))
+	assert.Equal(t, "unsupported type: map[string]int", err.Error())
+
+	_, err = Marshal(struct {
+		Foo struct {
+			Bar chan int
+		}
+	}{})
+	assert.True(t, errors.Is(err, ErrUnsupportedType))
+	assert.Equal(t, "Foo: Bar: unsupported type: chan int", err.Error())
+
+	_, err = Marshal(struct {
+		Foo string `fpack:"varint"`
+	}{})
+	assert.True(t, errors.Is(err, ErrInvalidTag))
+
+	_, err = Marshal(struct {
+		Foo string `fpack:"fixstring:3"`
+	}{})
+	assert.True(t, errors.Is(err, ErrInvalidTag))
+
+	_, err = Marshal(struct {
+		Foo string `fpack:"foo"`
+	}{})
+	assert.True(t, errors.Is(err, ErrInvalidTag))
+
+	var num int8
+	err = Unmarshal([]byte{0}, num)
+	assert.True(t, errors.Is(err, ErrUnsupportedType))
+
+	err = Unmarshal([]byte{0x80, 0x02}, &struct {
+		Num int8 `fpack:"varint"`
+	}{})
+	assert.ErrorIs(t, err, ErrNumberOverflow)
+
+	err = Unmarshal([]byte{5, 1}, &[]uint16{})
+	assert.ErrorIs(t, err, ErrListTooLong)
+}
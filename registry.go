@@ -0,0 +1,139 @@
+package fpack
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// ErrUnknownType is returned if a decoded type ID is not registered.
+var ErrUnknownType = errors.New("unknown type")
+
+// UnknownTypeError is returned if a decoded type ID is not registered. It
+// matches ErrUnknownType using errors.Is.
+type UnknownTypeError struct {
+	ID uint16
+}
+
+// Error implements the error interface.
+func (e *UnknownTypeError) Error() string {
+	return fmt.Sprintf("unknown type: %d", e.ID)
+}
+
+// Unwrap returns ErrUnknownType.
+func (e *UnknownTypeError) Unwrap() error {
+	return ErrUnknownType
+}
+
+type registryEntry struct {
+	id  uint16
+	enc func(enc *Encoder, v any)
+	dec func(dec *Decoder) any
+}
+
+// Registry maps type IDs to codecs to encode and decode values of different
+// types using a common envelope.
+type Registry struct {
+	ids   map[uint16]*registryEntry
+	types map[reflect.Type]*registryEntry
+	mutex sync.RWMutex
+}
+
+// NewRegistry creates and returns a new registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		ids:   map[uint16]*registryEntry{},
+		types: map[reflect.Type]*registryEntry{},
+	}
+}
+
+// Register will register the provided codec with the specified type ID. The
+// type must be concrete as values are looked up by their dynamic type. It
+// panics if the ID or type is already registered.
+func Register[T any](reg *Registry, id uint16, codec Codec[T]) {
+	// acquire mutex
+	reg.mutex.Lock()
+	defer reg.mutex.Unlock()
+
+	// check ID and type
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+	if _, ok := reg.ids[id]; ok {
+		panic(fmt.Sprintf("fpack: type ID %d already registered", id))
+	} else if _, ok := reg.types[typ]; ok {
+		panic(fmt.Sprintf("fpack: type %s already registered", typ))
+	}
+
+	// add entry
+	entry := &registryEntry{
+		id: id,
+		enc: func(enc *Encoder, v any) {
+			codec.Encode(enc, v.(T))
+		},
+		dec: func(dec *Decoder) any {
+			var v T
+			codec.Decode(dec, &v)
+			return v
+		},
+	}
+	reg.ids[id] = entry
+	reg.types[typ] = entry
+}
+
+func (r *Registry) lookup(id uint16) *registryEntry {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.ids[id]
+}
+
+func (r *Registry) find(v any) *registryEntry {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.types[reflect.TypeOf(v)]
+}
+
+// EncodeAny will encode the provided value using the registered codec of its
+// type. The type ID is written as a 16-bit unsigned integer followed by the
+// payload. Values of unregistered types result in ErrUnsupportedType.
+func EncodeAny(pool *Pool, reg *Registry, v any) ([]byte, Ref, error) {
+	// find entry
+	entry := reg.find(v)
+	if entry == nil {
+		return nil, Ref{}, fmt.Errorf("%w: %T", ErrUnsupportedType, v)
+	}
+
+	return Encode(pool, func(enc *Encoder) error {
+		enc.Uint16(entry.id)
+		entry.enc(enc, v)
+		return nil
+	})
+}
+
+// DecodeAny will decode a value encoded by EncodeAny using the registered codec
+// of the type ID. Unregistered type IDs result in an UnknownTypeError.
+func DecodeAny(reg *Registry, buf []byte) (any, error) {
+	var v any
+	err := Decode(buf, func(dec *Decoder) error {
+		// read ID
+		id := dec.Uint16()
+		if dec.Error() != nil {
+			return nil
+		}
+
+		// lookup entry
+		entry := reg.lookup(id)
+		if entry == nil {
+			return &UnknownTypeError{ID: id}
+		}
+
+		// decode value
+		v = entry.dec(dec)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
@@ -0,0 +1,68 @@
+package fpack
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var stringCodec = Codec[string]{
+	Encode: func(enc *Encoder, v string) {
+		enc.VarString(v)
+	},
+	Decode: func(dec *Decoder, v *string) {
+		*v = dec.VarString(true)
+	},
+}
+
+func TestRegistry(t *testing.T) {
+	reg := NewRegistry()
+	Register(reg, 1, pointCodec)
+	Register(reg, 2, stringCodec)
+
+	withAndWithoutPool(func(pool *Pool) {
+		buf, ref, err := EncodeAny(pool, reg, point{X: 1, Y: 2})
+		assert.NoError(t, err)
+		assert.Equal(t, []byte{0, 1, 0, 0, 0, 1, 0, 0, 0, 2}, buf)
+
+		v, err := DecodeAny(reg, buf)
+		assert.NoError(t, err)
+		assert.Equal(t, point{X: 1, Y: 2}, v)
+		ref.Release()
+
+		buf, ref, err = EncodeAny(pool, reg, "foo")
+		assert.NoError(t, err)
+		assert.Equal(t, []byte{0, 2, 3, 'f', 'o', 'o'}, buf)
+
+		v, err = DecodeAny(reg, buf)
+		assert.NoError(t, err)
+		assert.Equal(t, "foo", v)
+		ref.Release()
+	})
+
+	buf, _, err := EncodeAny(nil, reg, 42)
+	assert.True(t, errors.Is(err, ErrUnsupportedType))
+	assert.Nil(t, buf)
+
+	v, err := DecodeAny(reg, []byte{0, 3, 1})
+	assert.Equal(t, &UnknownTypeError{ID: 3}, err)
+	assert.True(t, errors.Is(err, ErrUnknownType))
+	assert.EqualError(t, err, "unknown type: 3")
+	assert.Nil(t, v)
+
+	v, err = DecodeAny(reg, []byte{0})
+	assert.ErrorIs(t, err, ErrBufferTooShort)
+	assert.Nil(t, v)
+
+	v, err = DecodeAny(reg, []byte{0, 1, 0, 0, 0, 1})
+	assert.ErrorIs(t, err, ErrBufferTooShort)
+	assert.Nil(t, v)
+
+	assert.PanicsWithValue(t, "fpack: type ID 1 already registered", func() {
+		Register(reg, 1, stringCodec)
+	})
+	assert.PanicsWithValue(t, "fpack: type fpack.point already registered", func() {
+		Register(reg, 3, pointCodec)
+	})
+}
@@ -0,0 +1,81 @@
+package fpack
+
+import "errors"
+
+// ErrNeedMore is returned by a stream decoder if more data is needed to
+// continue decoding.
+var ErrNeedMore = errors.New("need more data")
+
+// StreamDecoder decodes data that is received incrementally. Reads that run
+// past the buffered data set ErrNeedMore instead of ErrBufferTooShort. Decoded
+// data is kept until committed and decoding may be rolled back to the last
+// commit to be retried once more data has been appended.
+type StreamDecoder struct {
+	buf []byte
+	off int
+	pos int
+	dec Decoder
+}
+
+// NewStreamDecoder creates and returns a new stream decoder.
+func NewStreamDecoder() *StreamDecoder {
+	return &StreamDecoder{}
+}
+
+// Append will append a copy of the provided data to the buffered data.
+// Committed data is discarded to reclaim space if possible.
+func (s *StreamDecoder) Append(data []byte) {
+	// discard committed data if all data is committed or the buffer is full
+	if s.off > 0 && (s.off == len(s.buf) || len(s.buf)+len(data) > cap(s.buf)) {
+		n := copy(s.buf, s.buf[s.off:])
+		s.buf = s.buf[:n]
+		s.pos -= s.off
+		s.off = 0
+	}
+
+	// append data
+	s.buf = append(s.buf, data...)
+}
+
+// Decode will decode data from the current position using the provided
+// decoding function. The position is advanced by the consumed bytes if the
+// decoding succeeds. The function may not consume all buffered data. If the
+// buffered data is insufficient, ErrNeedMore is returned. Strings and byte
+// slices that are not cloned are only valid until the next call to Append.
+func (s *StreamDecoder) Decode(fn func(dec *Decoder) error) error {
+	// prepare decoder
+	s.dec.Reset(s.buf[s.pos:])
+	s.dec.stm = true
+
+	// ensure reset
+	defer s.dec.Reset(nil)
+
+	// decode
+	err := fn(&s.dec)
+	if err == nil {
+		err = s.dec.Error()
+	}
+	if err != nil {
+		return err
+	}
+
+	// advance position
+	s.pos += s.dec.Offset()
+
+	return nil
+}
+
+// Commit will discard all data decoded since the last commit.
+func (s *StreamDecoder) Commit() {
+	s.off = s.pos
+}
+
+// Rollback will rewind the position to the last commit.
+func (s *StreamDecoder) Rollback() {
+	s.pos = s.off
+}
+
+// Buffered returns the number of buffered bytes after the current position.
+func (s *StreamDecoder) Buffered() int {
+	return len(s.buf) - s.pos
+}
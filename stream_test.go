@@ -0,0 +1,78 @@
+package fpack
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStreamDecoder(t *testing.T) {
+	sd := NewStreamDecoder()
+
+	var msgs []string
+	decode := func() error {
+		for {
+			err := sd.Decode(func(dec *Decoder) error {
+				dec.Uint8()
+				return nil
+			})
+			var msg string
+			if err == nil {
+				err = sd.Decode(func(dec *Decoder) error {
+					msg = dec.VarString(true)
+					return nil
+				})
+			}
+			if err != nil {
+				sd.Rollback()
+				return err
+			}
+			sd.Commit()
+			msgs = append(msgs, msg)
+		}
+	}
+
+	err := decode()
+	assert.Equal(t, ErrNeedMore, err)
+	assert.Empty(t, msgs)
+
+	sd.Append([]byte{1, 3, 'f'})
+	err = decode()
+	assert.Equal(t, ErrNeedMore, err)
+	assert.Empty(t, msgs)
+	assert.Equal(t, 3, sd.Buffered())
+
+	sd.Append([]byte{'o', 'o', 2, 3, 'b', 'a', 'r', 3})
+	err = decode()
+	assert.Equal(t, ErrNeedMore, err)
+	assert.Equal(t, []string{"foo", "bar"}, msgs)
+	assert.Equal(t, 1, sd.Buffered())
+
+	sd.Append([]byte{0})
+	err = decode()
+	assert.Equal(t, ErrNeedMore, err)
+	assert.Equal(t, []string{"foo", "bar", ""}, msgs)
+	assert.Equal(t, 0, sd.Buffered())
+
+	sd.Append([]byte{1, 2, 1, 2, 3})
+	assert.Len(t, sd.buf, 5)
+	err = sd.Decode(func(dec *Decoder) error {
+		dec.Uint8()
+		dec.Limit(2, func(dec *Decoder) error {
+			dec.Uint8()
+			dec.Uint16()
+			return nil
+		})
+		return nil
+	})
+	assert.Equal(t, ErrBufferTooShort, err)
+
+	err = sd.Decode(func(dec *Decoder) error {
+		dec.DetailErrors(true)
+		dec.Skip(10)
+		return nil
+	})
+	assert.True(t, errors.Is(err, ErrNeedMore))
+	assert.Equal(t, 5, sd.Buffered())
+}
@@ -0,0 +1,98 @@
+package fpack
+
+// TailGroup calls the provided function if bytes remain and returns whether it
+// has been called. It is meant for a block of optional trailing fields that
+// have been appended to a format together. Fields appended later should be
+// read using the other tail methods, which return a default once the buffer is
+// exhausted:
+//
+//	dec.TailGroup(func(dec *Decoder) {
+//		v.Flags = dec.Uint8()                  // added in v2
+//		v.Name = dec.TailVarString("", true)   // added in v3
+//		v.Count = dec.TailVarUint(1)           // added in v3
+//	})
+func (d *Decoder) TailGroup(fn func(dec *Decoder)) bool {
+	// check remaining
+	if !d.Remaining() {
+		return false
+	}
+
+	// decode group
+	fn(d)
+
+	return true
+}
+
+// TailUint8 reads a one byte unsigned integer or returns the provided default
+// if the buffer is exhausted or the decoder errored.
+func (d *Decoder) TailUint8(def uint8) uint8 {
+	if !d.Remaining() {
+		return def
+	}
+	return d.Uint8()
+}
+
+// TailUint16 reads a two byte unsigned integer or returns the provided default
+// if the buffer is exhausted or the decoder errored.
+func (d *Decoder) TailUint16(def uint16) uint16 {
+	if !d.Remaining() {
+		return def
+	}
+	return d.Uint16()
+}
+
+// TailUint32 reads a four byte unsigned integer or returns the provided default
+// if the buffer is exhausted or the decoder errored.
+func (d *Decoder) TailUint32(def uint32) uint32 {
+	if !d.Remaining() {
+		return def
+	}
+	return d.Uint32()
+}
+
+// TailUint64 reads an eight byte unsigned integer or returns the provided
+// default if the buffer is exhausted or the decoder errored.
+func (d *Decoder) TailUint64(def uint64) uint64 {
+	if !d.Remaining() {
+		return def
+	}
+	return d.Uint64()
+}
+
+// TailVarUint reads a variable unsigned integer or returns the provided default
+// if the buffer is exhausted or the decoder errored.
+func (d *Decoder) TailVarUint(def uint64) uint64 {
+	if !d.Remaining() {
+		return def
+	}
+	return d.VarUint()
+}
+
+// TailVarInt reads a variable signed integer or returns the provided default if
+// the buffer is exhausted or the decoder errored.
+func (d *Decoder) TailVarInt(def int64) int64 {
+	if !d.Remaining() {
+		return def
+	}
+	return d.VarInt()
+}
+
+// TailVarString reads a variable length prefixed string or returns the provided
+// default if the buffer is exhausted or the decoder errored. If the string is
+// not cloned it may change if the source byte slice changes.
+func (d *Decoder) TailVarString(def string, clone bool) string {
+	if !d.Remaining() {
+		return def
+	}
+	return d.VarString(clone)
+}
+
+// TailVarBytes reads a variable length prefixed byte slice or returns the
+// provided default if the buffer is exhausted or the decoder errored. If the
+// byte slice is not cloned it may change if the source byte slice changes.
+func (d *Decoder) TailVarBytes(def []byte, clone bool) []byte {
+	if !d.Remaining() {
+		return def
+	}
+	return d.VarBytes(clone)
+}
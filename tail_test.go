@@ -0,0 +1,88 @@
+package fpack
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type tailMessage struct {
+	ID    uint32
+	Flags uint8
+	Name  string
+	Count uint64
+}
+
+func encodeTailMessage(enc *Encoder, msg tailMessage, version int) {
+	enc.Uint32(msg.ID)
+	if version >= 2 {
+		enc.Uint8(msg.Flags)
+	}
+	if version >= 3 {
+		enc.VarString(msg.Name)
+		enc.VarUint(msg.Count)
+	}
+}
+
+func decodeTailMessage(dec *Decoder) tailMessage {
+	var msg tailMessage
+	msg.ID = dec.Uint32()
+	dec.TailGroup(func(dec *Decoder) {
+		msg.Flags = dec.Uint8()
+		msg.Name = dec.TailVarString("none", true)
+		msg.Count = dec.TailVarUint(1)
+	})
+	return msg
+}
+
+func TestDecodeTail(t *testing.T) {
+	msg := tailMessage{ID: 7, Flags: 3, Name: "foo", Count: 42}
+
+	for version, expected := range map[int]tailMessage{
+		1: {ID: 7},
+		2: {ID: 7, Flags: 3, Name: "none", Count: 1},
+		3: msg,
+	} {
+		buf, _, err := Encode(nil, func(enc *Encoder) error {
+			encodeTailMessage(enc, msg, version)
+			return nil
+		})
+		assert.NoError(t, err)
+
+		var out tailMessage
+		err = Decode(buf, func(dec *Decoder) error {
+			out = decodeTailMessage(dec)
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, expected, out, version)
+	}
+
+	err := Decode(nil, func(dec *Decoder) error {
+		assert.Equal(t, uint8(1), dec.TailUint8(1))
+		assert.Equal(t, uint16(2), dec.TailUint16(2))
+		assert.Equal(t, uint32(3), dec.TailUint32(3))
+		assert.Equal(t, uint64(4), dec.TailUint64(4))
+		assert.Equal(t, int64(-5), dec.TailVarInt(-5))
+		assert.Equal(t, []byte("foo"), dec.TailVarBytes([]byte("foo"), false))
+		return nil
+	})
+	assert.NoError(t, err)
+
+	err = Decode([]byte{1, 0, 2, 0, 0, 0, 3, 0, 0, 0, 0, 0, 0, 0, 4, 9, 3, 'f', 'o', 'o'}, func(dec *Decoder) error {
+		assert.Equal(t, uint8(1), dec.TailUint8(0))
+		assert.Equal(t, uint16(2), dec.TailUint16(0))
+		assert.Equal(t, uint32(3), dec.TailUint32(0))
+		assert.Equal(t, uint64(4), dec.TailUint64(0))
+		assert.Equal(t, int64(-5), dec.TailVarInt(0))
+		assert.Equal(t, []byte("foo"), dec.TailVarBytes(nil, false))
+		return nil
+	})
+	assert.NoError(t, err)
+
+	err = Decode([]byte{1}, func(dec *Decoder) error {
+		dec.TailUint16(0)
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrBufferTooShort)
+}
@@ -0,0 +1,267 @@
+package fpack
+
+import (
+	"encoding/binary"
+	"errors"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// ErrInvalidASCII is returned if a string contains non-ASCII characters.
+var ErrInvalidASCII = errors.New("invalid ascii")
+
+// PackedASCII writes an ASCII string using 7-bit packing (GSM style). The
+// characters are packed least significant bit first, so eight characters
+// occupy seven bytes. Non-ASCII characters result in ErrInvalidASCII.
+func (e *Encoder) PackedASCII(str string) {
+	// skip if errored
+	if e.err != nil {
+		return
+	}
+
+	// check string
+	for i := 0; i < len(str); i++ {
+		if str[i] >= 0x80 {
+			e.err = ErrInvalidASCII
+			return
+		}
+	}
+
+	// determine size
+	size := (len(str)*7 + 7) / 8
+
+	// handle length
+	if e.buf == nil {
+		if e.rcd {
+			e.render(size, func(enc *Encoder) {
+				enc.PackedASCII(str)
+			})
+		}
+		e.len += size
+		return
+	}
+
+	// grow buffer
+	if !e.grow("packedascii", size) {
+		return
+	}
+
+	// pack characters
+	var acc, bits uint
+	var pos int
+	for i := 0; i < len(str); i++ {
+		acc |= uint(str[i]) << bits
+		bits += 7
+		if bits >= 8 {
+			e.buf[pos] = byte(acc)
+			acc >>= 8
+			bits -= 8
+			pos++
+		}
+	}
+	if bits > 0 {
+		e.buf[pos] = byte(acc)
+	}
+
+	// trace
+	if e.trc != nil {
+		e.trc("packedascii", e.Offset(), size)
+	}
+
+	// slice
+	e.buf = e.buf[size:]
+}
+
+// PackedASCII reads the specified number of characters from a 7-bit packed
+// ASCII string. The returned string is always allocated, using the arena if
+// configured.
+func (d *Decoder) PackedASCII(count int) string {
+	// skip if errored
+	if d.err != nil {
+		return ""
+	}
+
+	// check count
+	if count < 0 {
+		d.fail(ErrInvalidSize, "packedascii", count)
+		return ""
+	}
+
+	// check length
+	size := (count*7 + 7) / 8
+	if !d.need(size) {
+		d.fail(ErrBufferTooShort, "packedascii", size)
+		return ""
+	}
+
+	// allocate buffer
+	var buf []byte
+	if d.arn != nil {
+		buf = d.arn.Get(count, false)
+	} else {
+		buf = make([]byte, count)
+	}
+
+	// unpack characters
+	for i := range buf {
+		idx, shift := i*7/8, uint(i*7%8)
+		c := d.buf[idx] >> shift
+		if shift > 1 {
+			c |= d.buf[idx+1] << (8 - shift)
+		}
+		buf[i] = c & 0x7F
+	}
+
+	// trace
+	if d.trc != nil {
+		d.trc("packedascii", d.Offset(), size, toString(buf))
+	}
+
+	// slice
+	d.buf = d.buf[size:]
+
+	return toString(buf)
+}
+
+// UTF16String writes a string as fixed length prefixed UTF-16 code units using
+// the configured byte order. The prefix holds the number of code units. Runes
+// outside the basic multilingual plane are written as surrogate pairs and
+// invalid UTF-8 is replaced by U+FFFD.
+func (e *Encoder) UTF16String(str string, lenSize int) {
+	// check prefix
+	if !e.prefix("utf16string", lenSize) {
+		return
+	}
+
+	// count units
+	var units int
+	for _, r := range str {
+		if r >= 0x10000 {
+			units += 2
+		} else {
+			units++
+		}
+	}
+
+	// write length
+	e.Uint(uint64(units), lenSize)
+	if e.err != nil {
+		return
+	}
+
+	// handle length
+	if e.buf == nil {
+		if e.rcd {
+			e.render(units*2, func(enc *Encoder) {
+				putUTF16(enc.bo, enc.buf, str)
+			})
+		}
+		e.len += units * 2
+		return
+	}
+
+	// grow buffer
+	if !e.grow("utf16string", units*2) {
+		return
+	}
+
+	// write units
+	n := putUTF16(e.bo, e.buf, str)
+
+	// trace
+	if e.trc != nil {
+		e.trc("utf16string", e.Offset(), n)
+	}
+
+	// slice
+	e.buf = e.buf[n:]
+}
+
+func putUTF16(bo binary.ByteOrder, buf []byte, str string) int {
+	var pos int
+	for _, r := range str {
+		if r >= 0x10000 {
+			r1, r2 := utf16.EncodeRune(r)
+			bo.PutUint16(buf[pos:], uint16(r1))
+			bo.PutUint16(buf[pos+2:], uint16(r2))
+			pos += 4
+		} else {
+			bo.PutUint16(buf[pos:], uint16(r))
+			pos += 2
+		}
+	}
+	return pos
+}
+
+// UTF16String reads a string from fixed length prefixed UTF-16 code units
+// using the configured byte order. Unpaired surrogates are replaced by U+FFFD.
+// The returned string is always allocated, using the arena if configured.
+func (d *Decoder) UTF16String(lenSize int) string {
+	// check prefix
+	if !d.prefix("utf16string", lenSize) {
+		return ""
+	}
+
+	// read length
+	units := d.uint("utf16string", lenSize)
+	if d.err != nil {
+		return ""
+	}
+
+	// check length
+	if uint64(d.Length()/2) < units {
+		d.fail(ErrBufferTooShort, "utf16string", int(units*2))
+		return ""
+	}
+	d.need(int(units * 2))
+	data := d.buf[:units*2]
+
+	// prepare iterator
+	next := func(pos int) (rune, int) {
+		r1 := rune(d.bo.Uint16(data[pos:]))
+		if utf16.IsSurrogate(r1) && pos+4 <= len(data) {
+			r2 := rune(d.bo.Uint16(data[pos+2:]))
+			if r := utf16.DecodeRune(r1, r2); r != utf8.RuneError {
+				return r, 4
+			}
+		}
+		if utf16.IsSurrogate(r1) {
+			return utf8.RuneError, 2
+		}
+		return r1, 2
+	}
+
+	// determine length
+	var length int
+	for pos := 0; pos < len(data); {
+		r, n := next(pos)
+		length += utf8.RuneLen(r)
+		pos += n
+	}
+
+	// allocate buffer
+	var buf []byte
+	if d.arn != nil {
+		buf = d.arn.Get(length, false)
+	} else {
+		buf = make([]byte, length)
+	}
+
+	// encode runes
+	var off int
+	for pos := 0; pos < len(data); {
+		r, n := next(pos)
+		off += utf8.EncodeRune(buf[off:], r)
+		pos += n
+	}
+
+	// trace
+	if d.trc != nil {
+		d.trc("utf16string", d.Offset(), len(data), toString(buf))
+	}
+
+	// slice
+	d.buf = d.buf[len(data):]
+
+	return toString(buf)
+}
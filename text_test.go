@@ -0,0 +1,96 @@
+package fpack
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPackedASCII(t *testing.T) {
+	buf, _, err := Encode(nil, func(enc *Encoder) error {
+		enc.PackedASCII("hellohello")
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0xE8, 0x32, 0x9B, 0xFD, 0x46, 0x97, 0xD9, 0xEC, 0x37}, buf)
+
+	for i := 0; i <= 17; i++ {
+		str := strings.Repeat("\x7Fa", 9)[:i]
+
+		buf, _, err := Encode(nil, func(enc *Encoder) error {
+			enc.PackedASCII(str)
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Len(t, buf, (i*7+7)/8)
+
+		var res string
+		err = Decode(buf, func(dec *Decoder) error {
+			res = dec.PackedASCII(i)
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, str, res)
+	}
+
+	_, _, err = Encode(nil, func(enc *Encoder) error {
+		enc.PackedASCII("héllo")
+		return nil
+	})
+	assert.Equal(t, ErrInvalidASCII, err)
+
+	err = Decode([]byte{0xE8, 0x32}, func(dec *Decoder) error {
+		dec.PackedASCII(3)
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrBufferTooShort)
+
+	err = Decode(nil, func(dec *Decoder) error {
+		dec.PackedASCII(-1)
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrInvalidSize)
+}
+
+func TestUTF16String(t *testing.T) {
+	buf, _, err := Encode(nil, func(enc *Encoder) error {
+		enc.UTF16String("a€😀", 1)
+		enc.UseLittleEndian()
+		enc.UTF16String("a", 2)
+		enc.UTF16String("\xFF", 2)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{
+		4, 0x00, 0x61, 0x20, 0xAC, 0xD8, 0x3D, 0xDE, 0x00,
+		1, 0, 0x61, 0x00,
+		1, 0, 0xFD, 0xFF,
+	}, buf)
+
+	var str1, str2, str3 string
+	err = Decode(buf, func(dec *Decoder) error {
+		str1 = dec.UTF16String(1)
+		dec.UseLittleEndian()
+		str2 = dec.UTF16String(2)
+		str3 = dec.UTF16String(2)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "a€😀", str1)
+	assert.Equal(t, "a", str2)
+	assert.Equal(t, "�", str3)
+
+	err = Decode([]byte{3, 0xD8, 0x3D, 0x00, 0x61, 0xDE, 0x00}, func(dec *Decoder) error {
+		str1 = dec.UTF16String(1)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "�a�", str1)
+
+	err = Decode([]byte{2, 0x00, 0x61, 0x00}, func(dec *Decoder) error {
+		dec.UTF16String(1)
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrBufferTooShort)
+}
@@ -0,0 +1,44 @@
+package fpack
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DumpDecode will decode the provided buffer like Decode while tracing all
+// reads. It returns an annotated hex dump listing the offset, the bytes, the
+// operation and the decoded value of every read. The dump is also returned
+// if decoding failed.
+func DumpDecode(buf []byte, fn func(dec *Decoder) error) (string, error) {
+	// prepare output
+	var out strings.Builder
+
+	// decode
+	err := Decode(buf, func(dec *Decoder) error {
+		dec.trc = func(op string, offset, length int, value interface{}) {
+			// format bytes
+			data := fmt.Sprintf("% x", buf[offset:offset+length])
+			if length > 8 {
+				data = fmt.Sprintf("% x ...", buf[offset:offset+8])
+			}
+
+			// format value
+			var val string
+			switch value := value.(type) {
+			case nil:
+			case string:
+				val = fmt.Sprintf("%q", value)
+			case []byte:
+				val = fmt.Sprintf("%x", value)
+			default:
+				val = fmt.Sprintf("%v", value)
+			}
+
+			// write line
+			_, _ = fmt.Fprintf(&out, "%08d  %-27s  %-10s  %s\n", offset, data, op, val)
+		}
+		return fn(dec)
+	})
+
+	return out.String(), err
+}
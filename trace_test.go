@@ -0,0 +1,65 @@
+package fpack
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncoderTrace(t *testing.T) {
+	var ops []string
+	buf, _, err := Encode(nil, func(enc *Encoder) error {
+		enc.Trace(func(op string, offset, length int) {
+			ops = append(ops, fmt.Sprintf("%s@%d:%d", op, offset, length))
+		})
+		enc.Uint16(1)
+		enc.VarString("foo")
+		enc.Bool(true)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Len(t, buf, 7)
+	assert.Equal(t, []string{
+		"uint@0:2",
+		"varuint@2:1",
+		"string@3:3",
+		"uint@6:1",
+	}, ops)
+}
+
+func TestDecoderTrace(t *testing.T) {
+	var ops []string
+	err := Decode([]byte{0, 1, 3, 'f', 'o', 'o', 1}, func(dec *Decoder) error {
+		dec.Trace(func(op string, offset, length int) {
+			ops = append(ops, fmt.Sprintf("%s@%d:%d", op, offset, length))
+		})
+		dec.Uint16()
+		dec.VarString(false)
+		dec.Bool()
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"uint@0:2",
+		"varuint@2:1",
+		"string@3:3",
+		"uint@6:1",
+	}, ops)
+}
+
+func TestDumpDecode(t *testing.T) {
+	buf := []byte{0, 1, 12, 'H', 'e', 'l', 'l', 'o', ' ', 'W', 'o', 'r', 'l', 'd', '!', 2, 0xFF}
+	dump, err := DumpDecode(buf, func(dec *Decoder) error {
+		dec.Uint16()
+		dec.VarString(false)
+		dec.VarBytes(false)
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrBufferTooShort)
+	assert.Equal(t, ""+
+		"00000000  00 01                        uint        1\n"+
+		"00000002  0c                           varuint     12\n"+
+		"00000003  48 65 6c 6c 6f 20 57 6f ...  string      \"Hello World!\"\n"+
+		"00000015  02                           varuint     2\n", dump)
+}
@@ -0,0 +1,70 @@
+package fpack
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrUnsupportedType is returned if a value has an unsupported type.
+var ErrUnsupportedType = errors.New("unsupported type")
+
+// EncodeTuple will encode the provided parts as an order preserving composite
+// key. Supported types are string, []byte, int64, uint64, float64 and
+// time.Time. Strings and byte slices are encoded using OrderedString, signed
+// integers using OrderedInt64, unsigned integers in big endian, floats using
+// OrderedFloat64 and times as ordered Unix seconds followed by a four byte
+// nanosecond offset.
+func EncodeTuple(pool *Pool, parts ...any) ([]byte, Ref, error) {
+	return Encode(pool, func(enc *Encoder) error {
+		for _, part := range parts {
+			switch part := part.(type) {
+			case string:
+				enc.OrderedString(part)
+			case []byte:
+				enc.OrderedString(toString(part))
+			case int64:
+				enc.OrderedInt64(part)
+			case uint64:
+				enc.Uint64BE(part)
+			case float64:
+				enc.OrderedFloat64(part)
+			case time.Time:
+				enc.OrderedInt64(part.Unix())
+				enc.Uint32BE(uint32(part.Nanosecond()))
+			default:
+				return ErrUnsupportedType
+			}
+		}
+		return nil
+	})
+}
+
+// DecodeTuple will decode a composite key created by EncodeTuple into the
+// provided pointers. Decoded strings and byte slices are cloned and times are
+// returned in UTC. It returns ErrUnsupportedType for unsupported pointers and
+// ErrRemainingBytes if the key has not been fully consumed.
+func DecodeTuple(buf []byte, dst ...any) error {
+	return Decode(buf, func(dec *Decoder) error {
+		for _, part := range dst {
+			switch part := part.(type) {
+			case *string:
+				*part = dec.OrderedString(true)
+			case *[]byte:
+				*part = []byte(dec.OrderedString(false))
+			case *int64:
+				*part = dec.OrderedInt64()
+			case *uint64:
+				*part = dec.Uint64BE()
+			case *float64:
+				*part = dec.OrderedFloat64()
+			case *time.Time:
+				sec := dec.OrderedInt64()
+				nsec := dec.Uint32BE()
+				*part = time.Unix(sec, int64(nsec)).UTC()
+			default:
+				return ErrUnsupportedType
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,77 @@
+package fpack
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTuple(t *testing.T) {
+	key, ref, err := EncodeTuple(Global(), "foo", []byte("b\x00r"), int64(-42), uint64(42), 4.2, now)
+	assert.NoError(t, err)
+	defer ref.Release()
+
+	var str string
+	var buf []byte
+	var i64 int64
+	var u64 uint64
+	var f64 float64
+	var ts time.Time
+	err = DecodeTuple(key, &str, &buf, &i64, &u64, &f64, &ts)
+	assert.NoError(t, err)
+	assert.Equal(t, "foo", str)
+	assert.Equal(t, []byte("b\x00r"), buf)
+	assert.Equal(t, int64(-42), i64)
+	assert.Equal(t, uint64(42), u64)
+	assert.Equal(t, 4.2, f64)
+	assert.Equal(t, now, ts)
+
+	err = DecodeTuple(key, &str)
+	assert.ErrorIs(t, err, ErrRemainingBytes)
+
+	err = DecodeTuple(key, &str, &buf, &i64, &u64, &f64, &ts, &str)
+	assert.ErrorIs(t, err, ErrBufferTooShort)
+
+	err = DecodeTuple(key, &str, new(int))
+	assert.Equal(t, ErrUnsupportedType, err)
+
+	_, _, err = EncodeTuple(nil, 42)
+	assert.Equal(t, ErrUnsupportedType, err)
+}
+
+func TestTupleBytes(t *testing.T) {
+	key, _, err := EncodeTuple(nil, []byte("a"), []byte("b\x00"))
+	assert.NoError(t, err)
+
+	var b1, b2 []byte
+	err = DecodeTuple(key, &b1, &b2)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("a"), b1)
+	assert.Equal(t, []byte("b\x00"), b2)
+
+	b1[0] = 'z'
+	b2[0] = 'z'
+	assert.Equal(t, []byte("z"), b1)
+	assert.Equal(t, []byte("z\x00"), b2)
+	assert.Equal(t, []byte("a\x00\x01b\x00\xFF\x00\x01"), key)
+}
+
+func TestTupleSorting(t *testing.T) {
+	key1, _, err := EncodeTuple(nil, "a", int64(5), now)
+	assert.NoError(t, err)
+
+	key2, _, err := EncodeTuple(nil, "a", int64(5), now.Add(time.Nanosecond))
+	assert.NoError(t, err)
+
+	key3, _, err := EncodeTuple(nil, "a", int64(6), now.Add(-time.Hour))
+	assert.NoError(t, err)
+
+	key4, _, err := EncodeTuple(nil, "a\x00", int64(-5), now)
+	assert.NoError(t, err)
+
+	assert.Equal(t, -1, bytes.Compare(key1, key2))
+	assert.Equal(t, -1, bytes.Compare(key2, key3))
+	assert.Equal(t, -1, bytes.Compare(key3, key4))
+}